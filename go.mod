@@ -10,3 +10,7 @@ require (
 	github.com/maragudk/gomponents v0.20.5
 	github.com/maragudk/gomponents-htmx v0.5.0
 )
+
+require gopkg.in/yaml.v3 v3.0.1
+
+require github.com/andybalholm/brotli v1.1.1
@@ -0,0 +1,224 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/geo"
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// Rule describes surf conditions at a surf break that a user wants to be alerted about.
+type Rule struct {
+	ID      string `json:"id"`
+	BreakID int    `json:"break_id"`
+	Criteria
+}
+
+// Validate checks if the rule is valid.
+func (r Rule) Validate() error {
+	if r.BreakID <= 0 {
+		return errors.New("break id must be positive")
+	}
+	return r.Criteria.Validate()
+}
+
+// Criteria holds thresholds that an hourly forecast must meet. Zero values impose
+// no restrictions.
+type Criteria struct {
+	MinWaveHeightInMeters float64 `json:"min_wave_height_m,omitempty"`
+	MaxWaveHeightInMeters float64 `json:"max_wave_height_m,omitempty"`
+	MinPeriodInSeconds    float64 `json:"min_period_s,omitempty"`
+
+	// WindStates holds the accepted wind states (i.e. "offshore", "cross-off", etc.).
+	WindStates []string `json:"wind_states,omitempty"`
+
+	// MinRating holds the minimum rating. See meteo365.HourlyForecast.Rating.
+	MinRating int `json:"min_rating,omitempty"`
+
+	// DaylightOnly restricts matches to the hours between sunrise and sunset.
+	DaylightOnly bool `json:"daylight_only,omitempty"`
+}
+
+// Validate checks if the criteria are valid.
+func (c Criteria) Validate() error {
+	if c.MinWaveHeightInMeters < 0 {
+		return errors.New("min wave height must not be negative")
+	}
+	if c.MaxWaveHeightInMeters < 0 {
+		return errors.New("max wave height must not be negative")
+	}
+	if c.MaxWaveHeightInMeters > 0 && c.MaxWaveHeightInMeters < c.MinWaveHeightInMeters {
+		return errors.New("max wave height must not be less than min wave height")
+	}
+	if c.MinPeriodInSeconds < 0 {
+		return errors.New("min period must not be negative")
+	}
+	if c.MinRating < 0 || c.MinRating > 10 {
+		return errors.New("min rating must range from 0 to 10")
+	}
+	for _, s := range c.WindStates {
+		if strings.TrimSpace(s) == "" {
+			return errors.New("wind states must not be blank")
+		}
+	}
+	return nil
+}
+
+// Matches checks if an hourly forecast of a surf break located at the given point
+// meets the criteria. The location is only needed for checking daylight, and when
+// it is unknown the hours from 6 am to 8 pm are considered daylight.
+func (c Criteria) Matches(hf meteo365.HourlyForecast, location *geo.Point) bool {
+	height := hf.Swells.Primary.WaveHeightInMeters
+	if height < c.MinWaveHeightInMeters {
+		return false
+	}
+	if c.MaxWaveHeightInMeters > 0 && height > c.MaxWaveHeightInMeters {
+		return false
+	}
+	if hf.Swells.Primary.PeriodInSeconds < c.MinPeriodInSeconds {
+		return false
+	}
+	if len(c.WindStates) > 0 && !slices.ContainsFunc(c.WindStates, func(s string) bool {
+		return strings.EqualFold(s, hf.Wind.State)
+	}) {
+		return false
+	}
+	if hf.Rating < c.MinRating {
+		return false
+	}
+	if c.DaylightOnly && !isDaylight(hf.Timestamp, location) {
+		return false
+	}
+	return true
+}
+
+func isDaylight(t time.Time, location *geo.Point) bool {
+	if location == nil {
+		return t.Hour() >= 6 && t.Hour() <= 20
+	}
+	return geo.IsDaylight(*location, t)
+}
+
+// defaultHourlyStep is the assumed duration of an hourly forecast when it cannot be
+// derived from the adjacent ones.
+const defaultHourlyStep = 3 * time.Hour
+
+// FindWindows returns windows of contiguous hourly forecasts of a forecast issue that
+// meet the given criteria.
+func FindWindows(iss *meteo365.ForecastIssue, c Criteria, location *geo.Point) []Window {
+	var hourly []meteo365.HourlyForecast
+	for _, df := range iss.Daily {
+		hourly = append(hourly, df.Hourly...)
+	}
+
+	var (
+		windows []Window
+		current *Window
+	)
+	for i, hf := range hourly {
+		if !c.Matches(hf, location) {
+			current = nil
+			continue
+		}
+
+		if current == nil {
+			windows = append(windows, Window{Start: hf.Timestamp})
+			current = &windows[len(windows)-1]
+		}
+
+		current.Hourly = append(current.Hourly, hf)
+		current.End = hf.Timestamp.Add(hourlyStep(hourly, i))
+	}
+	return windows
+}
+
+// hourlyStep returns the duration of the i-th hourly forecast, which lasts until the
+// next one starts.
+func hourlyStep(hourly []meteo365.HourlyForecast, i int) time.Duration {
+	if i+1 < len(hourly) {
+		return hourly[i+1].Timestamp.Sub(hourly[i].Timestamp)
+	}
+	if i > 0 {
+		return hourly[i].Timestamp.Sub(hourly[i-1].Timestamp)
+	}
+	return defaultHourlyStep
+}
+
+// Window holds contiguous hourly forecasts that meet some criteria.
+type Window struct {
+	Start  time.Time
+	End    time.Time
+	Hourly []meteo365.HourlyForecast
+}
+
+// Summary returns a brief description of the window's conditions based on its biggest
+// waves, longest period and prevailing wind state (i.e. "Chest high, 11 s, offshore").
+func (w Window) Summary() string {
+	var (
+		height, period float64
+		windStates     = make(map[string]int)
+		windState      string
+	)
+	for _, hf := range w.Hourly {
+		height = max(height, hf.Swells.Primary.WaveHeightInMeters)
+		period = max(period, hf.Swells.Primary.PeriodInSeconds)
+
+		windStates[hf.Wind.State]++
+		if windStates[hf.Wind.State] > windStates[windState] {
+			windState = hf.Wind.State
+		}
+	}
+
+	parts := []string{
+		meteo365.DescribeWaveHeight(height),
+		strconv.FormatFloat(period, 'f', -1, 64) + " s",
+	}
+	if windState != "" {
+		parts = append(parts, windState)
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Match holds a window of a surf break's forecast that matches a rule.
+type Match struct {
+	Rule     Rule
+	Break    meteo365.Break
+	IssuedAt time.Time
+	Window   Window
+}
+
+// Notifier delivers matches to users.
+type Notifier interface {
+	Notify(ctx context.Context, m Match) error
+}
+
+// NotifierFunc is an adapter that allows using an ordinary function as Notifier.
+type NotifierFunc func(ctx context.Context, m Match) error
+
+// Notify implements Notifier.
+func (fn NotifierFunc) Notify(ctx context.Context, m Match) error {
+	return fn(ctx, m)
+}
+
+// NewLogNotifier initializes a new Notifier that logs each match at the info level.
+func NewLogNotifier(logger *slog.Logger) Notifier {
+	return NotifierFunc(func(ctx context.Context, m Match) error {
+		logger.InfoContext(
+			ctx,
+			"alert matched",
+			"rule_id", m.Rule.ID,
+			"break_id", m.Break.ID,
+			"break_name", m.Break.Name,
+			"start", m.Window.Start,
+			"end", m.Window.End,
+		)
+		return nil
+	})
+}
@@ -0,0 +1,128 @@
+package alert
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/store"
+)
+
+// FiredStore is a local store of windows of rules that notifiers have already notified
+// about, which persists them as a JSON file so that they are not notified about again
+// after a restart. It is safe for concurrent use.
+type FiredStore struct {
+	path  string
+	mu    sync.Mutex
+	fired map[firedKey]time.Time
+}
+
+// firedKey identifies a window of a rule that a notifier has already notified about.
+type firedKey struct {
+	notifier string
+	ruleID   string
+	start    int64
+}
+
+// firedWindow is the persisted form of a window that has been notified about.
+type firedWindow struct {
+	Notifier string    `json:"notifier"`
+	RuleID   string    `json:"rule_id"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+}
+
+// OpenFiredStore initializes a new FiredStore that is backed by the file at the given
+// path. The file is created on the first write if it does not exist yet.
+func OpenFiredStore(path string) (*FiredStore, error) {
+	s := &FiredStore{
+		path:  path,
+		fired: make(map[firedKey]time.Time),
+	}
+
+	var windows []firedWindow
+	if _, err := store.ReadJSON(path, &windows); err != nil {
+		return nil, err
+	}
+
+	for _, w := range windows {
+		s.fired[firedKey{notifier: w.Notifier, ruleID: w.RuleID, start: w.Start.Unix()}] = w.End
+	}
+
+	return s, nil
+}
+
+func (s *FiredStore) has(key firedKey) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.fired[key]
+	return ok
+}
+
+// mark records a window that has been notified about. The window is remembered even
+// if the file cannot be written, so that it is not notified about again until a
+// restart.
+func (s *FiredStore) mark(key firedKey, end time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.fired[key] = end
+
+	if err := store.WriteJSON(s.path, s.sorted()); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	return nil
+}
+
+// forgetPast forgets the windows that have already ended.
+func (s *FiredStore) forgetPast(now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var forgotten bool
+	for key, end := range s.fired {
+		if !end.After(now) {
+			delete(s.fired, key)
+			forgotten = true
+		}
+	}
+
+	if !forgotten {
+		return nil
+	}
+
+	if err := store.WriteJSON(s.path, s.sorted()); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	return nil
+}
+
+// sorted returns the fired windows sorted by their notifiers, rule IDs and start times.
+// The caller must hold the lock.
+func (s *FiredStore) sorted() []firedWindow {
+	windows := make([]firedWindow, 0, len(s.fired))
+	for key, end := range s.fired {
+		windows = append(windows, firedWindow{
+			Notifier: key.notifier,
+			RuleID:   key.ruleID,
+			Start:    time.Unix(key.start, 0).UTC(),
+			End:      end,
+		})
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		if windows[i].Notifier != windows[j].Notifier {
+			return windows[i].Notifier < windows[j].Notifier
+		}
+		if windows[i].RuleID != windows[j].RuleID {
+			return windows[i].RuleID < windows[j].RuleID
+		}
+		return windows[i].Start.Before(windows[j].Start)
+	})
+
+	return windows
+}
@@ -0,0 +1,132 @@
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ztimes2/glassy/internal/store"
+)
+
+var (
+	// ErrRuleNotFound indicates that a rule could not be found.
+	ErrRuleNotFound = errors.New("rule not found")
+
+	// ErrTooManyRules indicates that no more rules can be stored.
+	ErrTooManyRules = errors.New("too many rules")
+)
+
+// MaxRules is the maximum number of stored rules, which bounds how much scraping the
+// rules cause.
+const MaxRules = 100
+
+// RuleStore is a local store of rules that persists them as a JSON file. It is safe
+// for concurrent use.
+type RuleStore struct {
+	path  string
+	mu    sync.RWMutex
+	rules map[string]Rule
+}
+
+// OpenRuleStore initializes a new RuleStore that is backed by the file at the given
+// path. The file is created on the first write if it does not exist yet.
+func OpenRuleStore(path string) (*RuleStore, error) {
+	s := &RuleStore{
+		path:  path,
+		rules: make(map[string]Rule),
+	}
+
+	var rules []Rule
+	if _, err := store.ReadJSON(path, &rules); err != nil {
+		return nil, err
+	}
+
+	for _, r := range rules {
+		s.rules[r.ID] = r
+	}
+
+	return s, nil
+}
+
+// Rules returns all the stored rules sorted by their IDs.
+func (s *RuleStore) Rules() []Rule {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted()
+}
+
+// Create validates and stores a new rule assigning it a random ID. It returns
+// ErrTooManyRules if MaxRules rules are stored already.
+func (s *RuleStore) Create(r Rule) (Rule, error) {
+	if err := r.Validate(); err != nil {
+		return Rule{}, fmt.Errorf("invalid rule: %w", err)
+	}
+
+	id, err := newRuleID()
+	if err != nil {
+		return Rule{}, fmt.Errorf("could not generate rule id: %w", err)
+	}
+	r.ID = id
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.rules) >= MaxRules {
+		return Rule{}, ErrTooManyRules
+	}
+
+	s.rules[r.ID] = r
+
+	if err := store.WriteJSON(s.path, s.sorted()); err != nil {
+		delete(s.rules, r.ID)
+		return Rule{}, fmt.Errorf("could not write file: %w", err)
+	}
+
+	return r, nil
+}
+
+// Delete deletes a rule by its ID. It returns ErrRuleNotFound for non-existent rules.
+func (s *RuleStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.rules[id]
+	if !ok {
+		return ErrRuleNotFound
+	}
+
+	delete(s.rules, id)
+
+	if err := store.WriteJSON(s.path, s.sorted()); err != nil {
+		s.rules[id] = r
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	return nil
+}
+
+// sorted returns the stored rules sorted by their IDs. The caller must hold the lock.
+func (s *RuleStore) sorted() []Rule {
+	rules := make([]Rule, 0, len(s.rules))
+	for _, r := range s.rules {
+		rules = append(rules, r)
+	}
+
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].ID < rules[j].ID
+	})
+
+	return rules
+}
+
+func newRuleID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,158 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/surf"
+)
+
+// RuleSource provides the rules to be evaluated.
+type RuleSource interface {
+	Rules() []Rule
+}
+
+// Scheduler periodically fetches the latest forecast issues of the surf breaks that
+// rules refer to, evaluates the rules, and hands new matches over to notifiers. Each
+// notifier only notifies about each window of a rule once, which is kept track of in
+// the given FiredStore, so that a failing notifier does not make the others notify
+// about the same window again.
+type Scheduler struct {
+	service   *surf.Service
+	rules     RuleSource
+	fired     *FiredStore
+	notifiers map[string]Notifier
+	interval  time.Duration
+	onError   func(error)
+}
+
+// NewScheduler initializes a new Scheduler that evaluates rules at the given interval.
+// The notifiers are keyed by names that identify them across restarts. Errors that
+// occur during evaluation are passed to onError which can be nil.
+func NewScheduler(
+	service *surf.Service,
+	rules RuleSource,
+	fired *FiredStore,
+	notifiers map[string]Notifier,
+	interval time.Duration,
+	onError func(error),
+) *Scheduler {
+
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	return &Scheduler{
+		service:   service,
+		rules:     rules,
+		fired:     fired,
+		notifiers: notifiers,
+		interval:  interval,
+		onError:   onError,
+	}
+}
+
+// Run evaluates the rules right away and then at every interval until the given
+// context is canceled.
+func (s *Scheduler) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := s.Evaluate(ctx); err != nil {
+			s.onError(err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Evaluate evaluates all the rules once and notifies about the windows that have not
+// been notified about yet.
+func (s *Scheduler) Evaluate(ctx context.Context) error {
+	var (
+		errs []error
+		byID = make(map[int][]Rule)
+	)
+
+	if err := s.fired.forgetPast(time.Now()); err != nil {
+		errs = append(errs, fmt.Errorf("could not forget past windows: %w", err))
+	}
+
+	for _, r := range s.rules.Rules() {
+		byID[r.BreakID] = append(byID[r.BreakID], r)
+	}
+
+	for breakID, rules := range byID {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		brk, err := s.service.Break(breakID)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not fetch surf break %d: %w", breakID, err))
+			continue
+		}
+
+		iss, err := s.service.LatestForecastIssue(brk)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not fetch forecast of surf break %d: %w", breakID, err))
+			continue
+		}
+
+		for _, r := range rules {
+			if err := s.evaluate(ctx, r, brk, iss); err != nil {
+				errs = append(errs, fmt.Errorf("could not evaluate rule %s: %w", r.ID, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (s *Scheduler) evaluate(ctx context.Context, r Rule, brk meteo365.Break, iss *meteo365.ForecastIssue) error {
+	now := time.Now()
+
+	var errs []error
+	for _, w := range FindWindows(iss, r.Criteria, brk.Location) {
+		if !w.End.After(now) {
+			continue
+		}
+
+		m := Match{
+			Rule:     r,
+			Break:    brk,
+			IssuedAt: iss.IssuedAt,
+			Window:   w,
+		}
+
+		for name, n := range s.notifiers {
+			key := firedKey{
+				notifier: name,
+				ruleID:   r.ID,
+				start:    w.Start.Unix(),
+			}
+			if s.fired.has(key) {
+				continue
+			}
+
+			if err := n.Notify(ctx, m); err != nil {
+				errs = append(errs, fmt.Errorf("could not notify %s: %w", name, err))
+				continue
+			}
+
+			if err := s.fired.mark(key, w.End); err != nil {
+				errs = append(errs, fmt.Errorf("could not record notified window: %w", err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,88 @@
+package webhook
+
+import (
+	"time"
+
+	"github.com/ztimes2/glassy/internal/alert"
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// payload is the JSON body posted to webhook URLs.
+type payload struct {
+	RuleID   string        `json:"rule_id"`
+	Break    payloadBreak  `json:"break"`
+	IssuedAt time.Time     `json:"issued_at"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Hourly   []payloadHour `json:"hourly"`
+}
+
+type payloadBreak struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	CountryName string `json:"country_name"`
+}
+
+type payloadHour struct {
+	Timestamp              time.Time      `json:"timestamp"`
+	Rating                 int            `json:"rating"`
+	Swell                  payloadSwell   `json:"swell"`
+	SecondarySwells        []payloadSwell `json:"secondary_swells,omitempty"`
+	WaveEnergyInKiloJoules float64        `json:"wave_energy_kj"`
+	Wind                   payloadWind    `json:"wind"`
+}
+
+type payloadSwell struct {
+	WaveHeightInMeters           float64 `json:"wave_height_m"`
+	PeriodInSeconds              float64 `json:"period_s"`
+	DirectionFromInCompassPoints string  `json:"direction_from"`
+}
+
+type payloadWind struct {
+	SpeedInKilometersPerHour     float64 `json:"speed_kmh"`
+	DirectionFromInCompassPoints string  `json:"direction_from"`
+	State                        string  `json:"state"`
+}
+
+func newPayload(m alert.Match) payload {
+	p := payload{
+		RuleID: m.Rule.ID,
+		Break: payloadBreak{
+			ID:          m.Break.ID,
+			Name:        m.Break.Name,
+			CountryName: m.Break.CountryName,
+		},
+		IssuedAt: m.IssuedAt,
+		Start:    m.Window.Start,
+		End:      m.Window.End,
+		Hourly:   make([]payloadHour, len(m.Window.Hourly)),
+	}
+
+	for i, hf := range m.Window.Hourly {
+		h := payloadHour{
+			Timestamp:              hf.Timestamp,
+			Rating:                 hf.Rating,
+			Swell:                  newPayloadSwell(hf.Swells.Primary),
+			WaveEnergyInKiloJoules: hf.WaveEnergyInKiloJoules,
+			Wind: payloadWind{
+				SpeedInKilometersPerHour:     hf.Wind.SpeedInKilometersPerHour,
+				DirectionFromInCompassPoints: hf.Wind.DirectionFromInCompassPoints,
+				State:                        hf.Wind.State,
+			},
+		}
+		for _, s := range hf.Swells.Secondary {
+			h.SecondarySwells = append(h.SecondarySwells, newPayloadSwell(s))
+		}
+		p.Hourly[i] = h
+	}
+
+	return p
+}
+
+func newPayloadSwell(s meteo365.Swell) payloadSwell {
+	return payloadSwell{
+		WaveHeightInMeters:           s.WaveHeightInMeters,
+		PeriodInSeconds:              s.PeriodInSeconds,
+		DirectionFromInCompassPoints: s.DirectionFromInCompassPoints,
+	}
+}
@@ -0,0 +1,265 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/alert"
+)
+
+const (
+	// HeaderSignature is the header holding an HMAC-SHA256 signature of a payload in
+	// the "sha256=<hex>" format. The signed message is the value of HeaderTimestamp,
+	// a dot, and the request body.
+	HeaderSignature = "X-Glassy-Signature"
+
+	// HeaderTimestamp is the header holding a Unix timestamp of when a payload was
+	// signed, which allows receivers to reject replayed requests.
+	HeaderTimestamp = "X-Glassy-Timestamp"
+
+	// HeaderDelivery is the header holding an ID of a delivery that stays the same
+	// across its retries.
+	HeaderDelivery = "X-Glassy-Delivery"
+)
+
+const (
+	defaultTimeout     = 10 * time.Second
+	defaultMaxAttempts = 5
+	defaultBaseDelay   = time.Second
+	defaultMaxDelay    = time.Minute
+	deliveryLogSize    = 100
+)
+
+// Notifier is an alert.Notifier that posts matches as signed JSON payloads to webhook
+// URLs, retrying failed deliveries with exponential backoff.
+type Notifier struct {
+	urls        []string
+	secret      []byte
+	client      *http.Client
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+	now         func() time.Time
+
+	mu         sync.Mutex
+	deliveries []Delivery
+}
+
+// NewNotifier initializes a new Notifier that posts to the given URLs and signs the
+// payloads with the given secret.
+func NewNotifier(urls []string, secret string) *Notifier {
+	return &Notifier{
+		urls:        urls,
+		secret:      []byte(secret),
+		client:      &http.Client{Timeout: defaultTimeout},
+		maxAttempts: defaultMaxAttempts,
+		baseDelay:   defaultBaseDelay,
+		maxDelay:    defaultMaxDelay,
+		now:         time.Now,
+	}
+}
+
+// Targets returns a Notifier for each of the webhook URLs, so that deliveries to each
+// of them can be kept track of separately. They are keyed by names that tell the URLs
+// apart without revealing them, since webhook URLs often hold secrets.
+func (n *Notifier) Targets() map[string]alert.Notifier {
+	targets := make(map[string]alert.Notifier, len(n.urls))
+	for _, u := range n.urls {
+		sum := sha256.Sum256([]byte(u))
+		targets["webhook:"+hex.EncodeToString(sum[:8])] = alert.NotifierFunc(func(ctx context.Context, m alert.Match) error {
+			body, err := json.Marshal(newPayload(m))
+			if err != nil {
+				return fmt.Errorf("could not marshal payload: %w", err)
+			}
+
+			if err := n.deliver(ctx, u, body); err != nil {
+				return fmt.Errorf("could not deliver to %s: %w", RedactURL(u), err)
+			}
+			return nil
+		})
+	}
+	return targets
+}
+
+// Deliveries returns the most recent delivery attempts from the newest to the oldest.
+func (n *Notifier) Deliveries() []Delivery {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	deliveries := make([]Delivery, len(n.deliveries))
+	for i, d := range n.deliveries {
+		deliveries[len(n.deliveries)-1-i] = d
+	}
+	return deliveries
+}
+
+// Delivery holds information about an attempt to deliver a payload to a webhook URL.
+// The URL is redacted by RedactURL.
+type Delivery struct {
+	ID         string        `json:"id"`
+	URL        string        `json:"url"`
+	Attempt    int           `json:"attempt"`
+	StatusCode int           `json:"status_code,omitempty"`
+	Error      string        `json:"error,omitempty"`
+	SentAt     time.Time     `json:"sent_at"`
+	Duration   time.Duration `json:"duration"`
+}
+
+func (n *Notifier) deliver(ctx context.Context, url string, body []byte) error {
+	id, err := newDeliveryID()
+	if err != nil {
+		return fmt.Errorf("could not generate delivery id: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 1; attempt <= n.maxAttempts; attempt++ {
+		if attempt > 1 {
+			if err := sleep(ctx, n.backoff(attempt-1)); err != nil {
+				return errors.Join(lastErr, err)
+			}
+		}
+
+		retryable, err := n.send(ctx, id, url, attempt, body)
+		if err == nil {
+			return nil
+		}
+		if !retryable {
+			return err
+		}
+		lastErr = err
+	}
+
+	return fmt.Errorf("gave up after %d attempts: %w", n.maxAttempts, lastErr)
+}
+
+// send makes a single delivery attempt and records it in the delivery log. It reports
+// whether a failed attempt is worth retrying.
+func (n *Notifier) send(ctx context.Context, id, url string, attempt int, body []byte) (bool, error) {
+	sentAt := n.now()
+	timestamp := strconv.FormatInt(sentAt.Unix(), 10)
+
+	d := Delivery{
+		ID:      id,
+		URL:     RedactURL(url),
+		Attempt: attempt,
+		SentAt:  sentAt,
+	}
+	defer func() {
+		d.Duration = n.now().Sub(sentAt)
+		n.record(d)
+	}()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		d.Error = err.Error()
+		return false, fmt.Errorf("could not prepare request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDelivery, id)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	req.Header.Set(HeaderSignature, "sha256="+Sign(n.secret, timestamp, body))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		// The error holds the URL.
+		var urlErr *neturl.Error
+		if errors.As(err, &urlErr) {
+			urlErr.URL = RedactURL(urlErr.URL)
+		}
+
+		d.Error = err.Error()
+		return ctx.Err() == nil, fmt.Errorf("could not send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	d.StatusCode = resp.StatusCode
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		err := fmt.Errorf("received response with %d status code", resp.StatusCode)
+		d.Error = err.Error()
+
+		retryable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+		return retryable, err
+	}
+
+	return false, nil
+}
+
+// Sign returns a hex-encoded HMAC-SHA256 signature of a payload that was sent at the
+// given Unix timestamp. Receivers can use it to verify HeaderSignature.
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// RedactURL returns the scheme and host of a URL with the rest redacted, since the
+// path and query of webhook URLs often hold secrets that allow posting to them.
+func RedactURL(u string) string {
+	parsed, err := neturl.Parse(u)
+	if err != nil || parsed.Host == "" {
+		return "[redacted]"
+	}
+
+	redacted := parsed.Scheme + "://" + parsed.Host
+	if parsed.Path != "" || parsed.RawQuery != "" {
+		redacted += "/[redacted]"
+	}
+	return redacted
+}
+
+// backoff returns a delay before the given retry which doubles with every retry.
+func (n *Notifier) backoff(retry int) time.Duration {
+	d := n.baseDelay << (retry - 1)
+	if d <= 0 || d > n.maxDelay {
+		return n.maxDelay
+	}
+	return d
+}
+
+func (n *Notifier) record(d Delivery) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.deliveries = append(n.deliveries, d)
+	if len(n.deliveries) > deliveryLogSize {
+		n.deliveries = n.deliveries[len(n.deliveries)-deliveryLogSize:]
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func newDeliveryID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
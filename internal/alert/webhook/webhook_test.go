@@ -0,0 +1,290 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/alert"
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+func newTestNotifier(url string) *Notifier {
+	n := NewNotifier([]string{url}, "s3cret")
+	n.baseDelay = time.Millisecond
+	n.maxDelay = 2 * time.Millisecond
+	return n
+}
+
+// notify delivers a match through the only target of the given notifier.
+func notify(ctx context.Context, t *testing.T, n *Notifier) error {
+	t.Helper()
+
+	targets := n.Targets()
+	if len(targets) != 1 {
+		t.Fatalf("expected 1 target, got %d", len(targets))
+	}
+
+	m := alert.Match{
+		Rule:     alert.Rule{ID: "rule1"},
+		Break:    meteo365.Break{ID: 42, Name: "Supertubos", CountryName: "Portugal"},
+		IssuedAt: time.Date(2024, 7, 1, 6, 0, 0, 0, time.UTC),
+	}
+	for _, target := range targets {
+		return target.Notify(ctx, m)
+	}
+	return nil
+}
+
+func TestNotifier_Signature(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests int
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests++
+		mu.Unlock()
+
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			t.Errorf("could not read body: %v", err)
+		}
+
+		if got := r.Header.Get("Content-Type"); got != "application/json" {
+			t.Errorf("unexpected content type: %q", got)
+		}
+		if r.Header.Get(HeaderDelivery) == "" {
+			t.Error("missing delivery id")
+		}
+
+		timestamp := r.Header.Get(HeaderTimestamp)
+		if _, err := strconv.ParseInt(timestamp, 10, 64); err != nil {
+			t.Errorf("invalid timestamp: %q", timestamp)
+		}
+
+		want := "sha256=" + Sign([]byte("s3cret"), timestamp, body)
+		if got := r.Header.Get(HeaderSignature); got != want {
+			t.Errorf("unexpected signature: got %q, want %q", got, want)
+		}
+
+		var p payload
+		if err := json.Unmarshal(body, &p); err != nil {
+			t.Errorf("could not unmarshal payload: %v", err)
+		}
+		if p.RuleID != "rule1" || p.Break.ID != 42 {
+			t.Errorf("unexpected payload: %+v", p)
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(srv.URL + "/hooks/secret-token")
+	if err := notify(context.Background(), t, n); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if requests != 1 {
+		t.Errorf("expected 1 request, got %d", requests)
+	}
+
+	deliveries := n.Deliveries()
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+
+	d := deliveries[0]
+	if d.URL != srv.URL+"/[redacted]" {
+		t.Errorf("unexpected url: %q", d.URL)
+	}
+	if d.Attempt != 1 || d.StatusCode != http.StatusNoContent || d.Error != "" {
+		t.Errorf("unexpected delivery: %+v", d)
+	}
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantErr   bool
+		wantCodes []int
+	}{
+		{
+			name:      "success",
+			statuses:  []int{http.StatusOK},
+			wantCodes: []int{http.StatusOK},
+		},
+		{
+			name:      "server errors are retried",
+			statuses:  []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+			wantCodes: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK},
+		},
+		{
+			name:      "too many requests are retried",
+			statuses:  []int{http.StatusTooManyRequests, http.StatusOK},
+			wantCodes: []int{http.StatusTooManyRequests, http.StatusOK},
+		},
+		{
+			name:      "client errors are not retried",
+			statuses:  []int{http.StatusBadRequest, http.StatusOK},
+			wantErr:   true,
+			wantCodes: []int{http.StatusBadRequest},
+		},
+		{
+			name: "gives up after max attempts",
+			statuses: []int{
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusOK,
+			},
+			wantErr: true,
+			wantCodes: []int{
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+				http.StatusServiceUnavailable,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu          sync.Mutex
+				i           int
+				deliveryIDs = make(map[string]struct{})
+			)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				deliveryIDs[r.Header.Get(HeaderDelivery)] = struct{}{}
+				w.WriteHeader(tt.statuses[i])
+				i++
+			}))
+			defer srv.Close()
+
+			n := newTestNotifier(srv.URL)
+			err := notify(context.Background(), t, n)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+
+			if len(deliveryIDs) != 1 {
+				t.Errorf("expected retries to keep the delivery id, got %d ids", len(deliveryIDs))
+			}
+
+			// The log goes from the newest to the oldest attempt.
+			deliveries := n.Deliveries()
+			if len(deliveries) != len(tt.wantCodes) {
+				t.Fatalf("expected %d deliveries, got %d", len(tt.wantCodes), len(deliveries))
+			}
+			for j, d := range deliveries {
+				attempt := len(deliveries) - j
+				if d.Attempt != attempt {
+					t.Errorf("delivery %d: expected attempt %d, got %d", j, attempt, d.Attempt)
+				}
+				if want := tt.wantCodes[attempt-1]; d.StatusCode != want {
+					t.Errorf("delivery %d: expected status code %d, got %d", j, want, d.StatusCode)
+				}
+				if failed := d.StatusCode >= 300; failed != (d.Error != "") {
+					t.Errorf("delivery %d: unexpected error %q", j, d.Error)
+				}
+			}
+		})
+	}
+}
+
+func TestNotifier_RetryCanceled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(srv.URL)
+	n.baseDelay = time.Hour
+	n.maxDelay = time.Hour
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err := notify(ctx, t, n)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected deadline exceeded, got %v", err)
+	}
+
+	if got := len(n.Deliveries()); got != 1 {
+		t.Errorf("expected 1 delivery, got %d", got)
+	}
+}
+
+func TestNotifier_Backoff(t *testing.T) {
+	n := NewNotifier(nil, "")
+
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{retry: 1, want: time.Second},
+		{retry: 2, want: 2 * time.Second},
+		{retry: 3, want: 4 * time.Second},
+		{retry: 6, want: 32 * time.Second},
+		{retry: 7, want: time.Minute},
+		{retry: 100, want: time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := n.backoff(tt.retry); got != tt.want {
+			t.Errorf("backoff(%d): expected %v, got %v", tt.retry, tt.want, got)
+		}
+	}
+}
+
+func TestNotifier_DeliveryLogSize(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	n := newTestNotifier(srv.URL)
+	for i := 0; i < deliveryLogSize+10; i++ {
+		if err := notify(context.Background(), t, n); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if got := len(n.Deliveries()); got != deliveryLogSize {
+		t.Errorf("expected %d deliveries, got %d", deliveryLogSize, got)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://hooks.example.com", want: "https://hooks.example.com"},
+		{url: "https://hooks.example.com/T0/B0/secret", want: "https://hooks.example.com/[redacted]"},
+		{url: "https://hooks.example.com?token=secret", want: "https://hooks.example.com/[redacted]"},
+		{url: "/hook", want: "[redacted]"},
+		{url: "://", want: "[redacted]"},
+	}
+
+	for _, tt := range tests {
+		if got := RedactURL(tt.url); got != tt.want {
+			t.Errorf("RedactURL(%q): expected %q, got %q", tt.url, tt.want, got)
+		}
+	}
+}
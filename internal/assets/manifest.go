@@ -0,0 +1,91 @@
+package assets
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// fingerprintLength is the number of hex digits of a content hash that are put in
+// fingerprinted names of files.
+const fingerprintLength = 10
+
+// Manifest maps names of static files to fingerprinted ones that contain hashes of
+// their content, e.g. "favicon-32x32.png" to "favicon-32x32.3f9a0c1d2e.png". Since a
+// fingerprinted name changes whenever the content does, files served by such names can
+// be cached by clients forever.
+type Manifest struct {
+	fsys fs.FS
+
+	// fingerprinted holds fingerprinted names by names, and names holds the names
+	// by fingerprinted ones.
+	fingerprinted map[string]string
+	names         map[string]string
+}
+
+// NewManifest initializes a new Manifest of all files of the given file system.
+func NewManifest(fsys fs.FS) (*Manifest, error) {
+	m := &Manifest{
+		fsys:          fsys,
+		fingerprinted: make(map[string]string),
+		names:         make(map[string]string),
+	}
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		content, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		fp := fingerprint(name, content)
+		m.fingerprinted[name] = fp
+		m.names[fp] = name
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// fingerprint inserts a hash of the given content before the extension of a name.
+func fingerprint(name string, content []byte) string {
+	sum := sha256.Sum256(content)
+	hash := hex.EncodeToString(sum[:])[:fingerprintLength]
+
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// FS returns the file system of the files.
+func (m *Manifest) FS() fs.FS {
+	return m.fsys
+}
+
+// URL returns the URL path of a file that refers to it by its fingerprinted name.
+// Unknown files are referred to by their names as they are.
+func (m *Manifest) URL(name string) string {
+	if m == nil {
+		return "/" + name
+	}
+	if fp, ok := m.fingerprinted[name]; ok {
+		return "/" + fp
+	}
+	return "/" + name
+}
+
+// Resolve returns the name of a file by its fingerprinted name. It returns false if
+// the given name is not a fingerprinted one.
+func (m *Manifest) Resolve(fingerprinted string) (string, bool) {
+	name, ok := m.names[fingerprinted]
+	return name, ok
+}
@@ -0,0 +1,84 @@
+package cache
+
+import (
+	"sync"
+	"time"
+)
+
+// Cache is an in-memory key-value cache whose entries expire after a fixed TTL.
+// It is safe for concurrent use.
+type Cache[K comparable, V any] struct {
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[K]entry[V]
+	stats   Stats
+}
+
+// Stats holds counts of cache lookups.
+type Stats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+type entry[V any] struct {
+	value     V
+	expiresAt time.Time
+}
+
+// New initializes a new Cache whose entries expire after the given TTL.
+func New[K comparable, V any](ttl time.Duration) *Cache[K, V] {
+	return &Cache[K, V]{
+		ttl:     ttl,
+		now:     time.Now,
+		entries: make(map[K]entry[V]),
+	}
+}
+
+// Get returns a value by its key. It returns false if the value is missing or expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok || !c.now().Before(e.expiresAt) {
+		c.stats.Misses++
+		var zero V
+		return zero, false
+	}
+
+	c.stats.Hits++
+	return e.value, true
+}
+
+// Stats returns counts of the lookups made so far.
+func (c *Cache[K, V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.stats
+}
+
+// Set stores a value by its key replacing the existing one if any.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.deleteExpired()
+
+	c.entries[key] = entry[V]{
+		value:     value,
+		expiresAt: c.now().Add(c.ttl),
+	}
+}
+
+// deleteExpired evicts expired entries so that the cache does not grow indefinitely.
+// The caller must hold the lock.
+func (c *Cache[K, V]) deleteExpired() {
+	now := c.now()
+	for k, e := range c.entries {
+		if !now.Before(e.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Usage describes the command-line interface.
+const Usage = `Usage: glassy <command> [flags] [arguments]
+
+Commands:
+  serve               Start the web server (default).
+  search <query>      Search for surf breaks, regions and countries.
+  forecast <id>       Print the latest forecast of a surf break by its ID.
+
+Flags of serve:
+  --config <path>     Read the configuration from a YAML file.
+  --listen <addr>     Address the web server listens on (default ":8080").
+  --log-level <level> Minimum level of logged messages (default "info").
+  --shutdown-timeout <d>
+                      How long shutdown waits for in-flight work (default 30s).
+  --log-format <f>    Format of logged messages, text or json (default "text").
+  --base-url <url>    Base URL of www.surf-forecast.com.
+  --timeout <d>       Timeout of requests to www.surf-forecast.com (default 10s).
+  --cache-max-age <d> How long clients may cache responses (default 1h).
+  --cache-ttl <d>     How long scraped data is reused (default 1h).
+  --data-dir <path>   Directory where data is persisted (default "data").
+
+Flags of search and forecast:
+  --json              Print the output as JSON.
+  --no-color          Disable colours. Colours are also disabled when the output is
+                      not a terminal or the NO_COLOR environment variable is set.
+
+Flags of forecast:
+  --days <n>          Print only the first n days.
+`
+
+// options holds the flags shared by the commands.
+type options struct {
+	json    bool
+	noColor bool
+}
+
+// parse parses the flags and the positional arguments of a command. Unlike the flag
+// package alone, it accepts flags that follow the positional arguments, so both
+// "glassy search --json pipeline" and "glassy search pipeline --json" work.
+func parse(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+func newFlagSet(name string, opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&opts.json, "json", false, "print the output as json")
+	fs.BoolVar(&opts.noColor, "no-color", false, "disable colours")
+	return fs
+}
+
+// colorEnabled checks if the output written to w should be colourised.
+func colorEnabled(w io.Writer, opts options) bool {
+	if opts.noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// ANSI escape codes of the colours used by the commands.
+const (
+	colorReset  = "\033[0m"
+	colorBold   = "\033[1m"
+	colorDim    = "\033[2m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorCyan   = "\033[36m"
+)
+
+// painter wraps text into ANSI escape codes if colours are enabled.
+type painter bool
+
+func (p painter) paint(color, s string) string {
+	if !p {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// pad pads a string with spaces to the given width. Unlike fmt's width, it counts
+// runes rather than bytes and ignores ANSI escape codes.
+func pad(s string, width int) string {
+	n := 0
+	for inEscape, r := false, []rune(s); len(r) > 0; r = r[1:] {
+		switch {
+		case r[0] == '\033':
+			inEscape = true
+		case inEscape:
+			inEscape = r[0] != 'm'
+		default:
+			n++
+		}
+	}
+	if n >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-n)
+}
+
+func usageError(format string, args ...any) error {
+	return fmt.Errorf(format+"\n\n%s", append(args, Usage)...)
+}
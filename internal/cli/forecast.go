@@ -0,0 +1,142 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/export"
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// Forecast runs the forecast command with the given arguments and writes its output
+// to w.
+func Forecast(scraper *meteo365.Scraper, args []string, w io.Writer) error {
+	var opts options
+	fs := newFlagSet("forecast", &opts)
+	days := fs.Int("days", 0, "number of days to print")
+
+	positional, err := parse(fs, args)
+	if err != nil {
+		return usageError("%s", err)
+	}
+	if len(positional) != 1 {
+		return usageError("surf break id must be provided")
+	}
+	if *days < 0 {
+		return usageError("number of days must not be negative")
+	}
+
+	id, err := strconv.Atoi(positional[0])
+	if err != nil {
+		return usageError("invalid surf break id: %q", positional[0])
+	}
+
+	brk, err := scraper.Break(id)
+	if err != nil {
+		if errors.Is(err, meteo365.ErrBreakNotFound) {
+			return fmt.Errorf("surf break %d not found", id)
+		}
+		return fmt.Errorf("could not fetch surf break: %w", err)
+	}
+
+	iss, err := scraper.LatestForecastIssue(brk.Slug)
+	if err != nil {
+		return fmt.Errorf("could not fetch forecast: %w", err)
+	}
+
+	if *days > 0 && *days < len(iss.Daily) {
+		iss = &meteo365.ForecastIssue{
+			IssuedAt: iss.IssuedAt,
+			Daily:    iss.Daily[:*days],
+		}
+	}
+
+	if opts.json {
+		return writeJSON(w, forecastOutput{
+			Break: forecastOutputBreak{
+				ID:          brk.ID,
+				Name:        brk.Name,
+				CountryName: brk.CountryName,
+			},
+			IssuedAt: iss.IssuedAt,
+			Hourly:   export.Rows(iss),
+		})
+	}
+
+	_, err = io.WriteString(w, forecastTable(brk, iss, painter(colorEnabled(w, opts))))
+	return err
+}
+
+type forecastOutput struct {
+	Break    forecastOutputBreak `json:"break"`
+	IssuedAt time.Time           `json:"issued_at"`
+	Hourly   []export.Row        `json:"hourly"`
+}
+
+type forecastOutputBreak struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	CountryName string `json:"country_name"`
+}
+
+// forecastTable renders a compact table of a forecast issue with a line per hour.
+func forecastTable(b meteo365.Break, iss *meteo365.ForecastIssue, p painter) string {
+	var sb strings.Builder
+
+	sb.WriteString(p.paint(colorBold, b.Name) + p.paint(colorDim, ", "+b.CountryName) + "\n")
+	sb.WriteString(p.paint(colorDim, "Issued "+iss.IssuedAt.Format("Mon 2 Jan, 3 pm MST")) + "\n")
+
+	for _, df := range iss.Daily {
+		sb.WriteString("\n" + p.paint(colorBold, df.Timestamp.Format("Monday 2 Jan")) + "\n")
+		sb.WriteString(p.paint(colorDim, fmt.Sprintf(
+			"  %-6s %-7s %-7s %-6s %-8s %-9s %-9s %s",
+			"", "Rating", "Height", "Period", "Dir", "Energy", "Wind", "State",
+		)) + "\n")
+
+		for _, hf := range df.Hourly {
+			cells := []string{
+				pad(hf.Timestamp.Format("3 pm"), 6),
+				pad(p.paint(ratingColor(hf.Rating), strconv.Itoa(hf.Rating)+"/10"), 7),
+				pad(formatFloat(hf.Swells.Primary.WaveHeightInMeters)+" m", 7),
+				pad(formatFloat(hf.Swells.Primary.PeriodInSeconds)+" s", 6),
+				pad(hf.Swells.Primary.DirectionFromInCompassPoints, 8),
+				pad(formatFloat(hf.WaveEnergyInKiloJoules)+" kJ", 9),
+				pad(formatFloat(hf.Wind.SpeedInKilometersPerHour)+" km/h", 9),
+				p.paint(windStateColor(hf.Wind.State), hf.Wind.State),
+			}
+			sb.WriteString("  " + strings.Join(cells, " ") + "\n")
+		}
+	}
+
+	return sb.String()
+}
+
+func ratingColor(rating int) string {
+	switch {
+	case rating >= 6:
+		return colorGreen
+	case rating >= 3:
+		return colorYellow
+	default:
+		return colorDim
+	}
+}
+
+func windStateColor(state string) string {
+	switch s := strings.ToLower(state); {
+	case s == "glass" || strings.HasPrefix(s, "off") || strings.HasPrefix(s, "cross-off"):
+		return colorGreen
+	case strings.HasPrefix(s, "on") || strings.HasPrefix(s, "cross-on"):
+		return colorRed
+	default:
+		return colorYellow
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
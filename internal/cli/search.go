@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// Search runs the search command with the given arguments and writes its output to w.
+func Search(scraper *meteo365.Scraper, args []string, w io.Writer) error {
+	var opts options
+	positional, err := parse(newFlagSet("search", &opts), args)
+	if err != nil {
+		return usageError("%s", err)
+	}
+
+	query := strings.TrimSpace(strings.Join(positional, " "))
+	if query == "" {
+		return usageError("search query must be provided")
+	}
+
+	results, err := scraper.Search(query)
+	if err != nil {
+		return fmt.Errorf("could not search: %w", err)
+	}
+
+	if opts.json {
+		return writeJSON(w, newSearchOutput(results))
+	}
+
+	if results.Empty() {
+		_, err := fmt.Fprintf(w, "Nothing found for %q.\n", query)
+		return err
+	}
+
+	p := painter(colorEnabled(w, opts))
+
+	var b strings.Builder
+	section := func(title string, rows [][2]string) {
+		if len(rows) == 0 {
+			return
+		}
+		b.WriteString(p.paint(colorBold, title) + "\n")
+		for _, row := range rows {
+			b.WriteString("  " + pad(p.paint(colorCyan, row[0]), 10) + row[1] + "\n")
+		}
+	}
+
+	var rows [][2]string
+	for _, r := range results.Breaks {
+		rows = append(rows, [2]string{strconv.Itoa(r.ID), r.Name + p.paint(colorDim, ", "+r.CountryName)})
+	}
+	section("Surf breaks", rows)
+
+	rows = nil
+	for _, r := range results.Regions {
+		rows = append(rows, [2]string{strconv.Itoa(r.ID), r.Name + p.paint(colorDim, ", "+r.CountryName)})
+	}
+	section("Regions", rows)
+
+	rows = nil
+	for _, r := range results.Countries {
+		rows = append(rows, [2]string{strconv.Itoa(r.ID), r.Name})
+	}
+	section("Countries", rows)
+
+	_, err = io.WriteString(w, b.String())
+	return err
+}
+
+type searchOutput struct {
+	Breaks    []searchOutputLocation `json:"breaks"`
+	Regions   []searchOutputLocation `json:"regions"`
+	Countries []searchOutputLocation `json:"countries"`
+}
+
+type searchOutputLocation struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	CountryName string `json:"country_name,omitempty"`
+}
+
+func newSearchOutput(results meteo365.SearchResults) searchOutput {
+	out := searchOutput{
+		Breaks:    make([]searchOutputLocation, 0, len(results.Breaks)),
+		Regions:   make([]searchOutputLocation, 0, len(results.Regions)),
+		Countries: make([]searchOutputLocation, 0, len(results.Countries)),
+	}
+	for _, r := range results.Breaks {
+		out.Breaks = append(out.Breaks, searchOutputLocation{ID: r.ID, Name: r.Name, CountryName: r.CountryName})
+	}
+	for _, r := range results.Regions {
+		out.Regions = append(out.Regions, searchOutputLocation{ID: r.ID, Name: r.Name, CountryName: r.CountryName})
+	}
+	for _, r := range results.Countries {
+		out.Countries = append(out.Countries, searchOutputLocation{ID: r.ID, Name: r.Name})
+	}
+	return out
+}
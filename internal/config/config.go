@@ -0,0 +1,683 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/alert/webhook"
+	"github.com/ztimes2/glassy/internal/digest"
+	"gopkg.in/yaml.v3"
+)
+
+// Config holds settings of the application.
+type Config struct {
+	// ListenAddr holds a TCP address the web server listens on.
+	ListenAddr string `yaml:"listen_addr"`
+
+	// LogLevel holds the minimum level of logged messages: "debug", "info", "warn"
+	// or "error".
+	LogLevel string `yaml:"log_level"`
+
+	// LogFormat holds the format of logged messages: "text" or "json".
+	LogFormat string `yaml:"log_format"`
+
+	// PublicURL holds the base URL the web server is reached at, which is used for
+	// linking from emails, calendars and feeds.
+	PublicURL string `yaml:"public_url"`
+
+	Server  Server  `yaml:"server"`
+	Scraper Scraper `yaml:"scraper"`
+	Cache   Cache   `yaml:"cache"`
+	Storage Storage `yaml:"storage"`
+	Alerts  Alerts  `yaml:"alerts"`
+	Digest  Digest  `yaml:"digest"`
+	Health  Health  `yaml:"health"`
+}
+
+// Server holds settings of the web server.
+type Server struct {
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout"`
+	ReadTimeout       time.Duration `yaml:"read_timeout"`
+	WriteTimeout      time.Duration `yaml:"write_timeout"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout"`
+
+	// ShutdownTimeout holds how long in-flight requests and background workers are
+	// waited for when shutting down.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+
+	// HSTS holds whether responses tell browsers to only reach the web server over
+	// HTTPS. It only takes effect if the public URL is an https one.
+	HSTS bool `yaml:"hsts"`
+}
+
+// Scraper holds settings of the web scraper of www.surf-forecast.com.
+type Scraper struct {
+	BaseURL string `yaml:"base_url"`
+
+	// Timeout holds the time limit of each request including its retries.
+	Timeout time.Duration `yaml:"timeout"`
+
+	// MaxRetries holds how many times a request failing with a transient error is
+	// retried.
+	MaxRetries int `yaml:"max_retries"`
+
+	// CircuitThreshold holds the number of consecutive failed requests after which
+	// requests fail fast for CircuitCooldown.
+	CircuitThreshold int           `yaml:"circuit_threshold"`
+	CircuitCooldown  time.Duration `yaml:"circuit_cooldown"`
+
+	// RateLimit holds how many requests per second are sent on average, and
+	// RateBurst holds how many can be sent at once after a quiet period.
+	RateLimit float64 `yaml:"rate_limit"`
+	RateBurst int     `yaml:"rate_burst"`
+
+	// MaxConcurrency holds how many requests can be in flight at once, and MaxQueue
+	// holds how many can wait for their turn before further ones are rejected.
+	MaxConcurrency int `yaml:"max_concurrency"`
+	MaxQueue       int `yaml:"max_queue"`
+
+	// UserAgent holds the User-Agent header that identifies the requests.
+	UserAgent string `yaml:"user_agent"`
+
+	// RespectRobots enables checking requests against robots.txt.
+	RespectRobots bool `yaml:"respect_robots"`
+}
+
+// Cache holds settings of caching.
+type Cache struct {
+	// MaxAge holds how long clients may cache the responses of the web server.
+	MaxAge time.Duration `yaml:"max_age"`
+
+	// TTL holds how long the scraped data is reused before being scraped again.
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Storage holds settings of the persisted data.
+type Storage struct {
+	// DataDir holds a directory where the application persists its data.
+	DataDir string `yaml:"data_dir"`
+
+	// ForecastRetention holds how long archived forecast issues are kept.
+	ForecastRetention time.Duration `yaml:"forecast_retention"`
+}
+
+// Alerts holds settings of condition alerts.
+type Alerts struct {
+	// Interval holds how often the alert rules are evaluated.
+	Interval time.Duration `yaml:"interval"`
+
+	// APIToken holds the bearer token that requests creating and deleting alert
+	// rules must carry. Alert rules cannot be changed if it is empty.
+	APIToken string `yaml:"api_token"`
+
+	// WebhookURLs holds URLs the matches are posted to. Webhooks are disabled if it
+	// is empty.
+	WebhookURLs   []string `yaml:"webhook_urls"`
+	WebhookSecret string   `yaml:"webhook_secret"`
+
+	// ExposeDeliveries enables the log of webhook deliveries at
+	// /alerts/webhooks/deliveries.
+	ExposeDeliveries bool `yaml:"expose_deliveries"`
+}
+
+// Digest holds settings of the daily email digest.
+type Digest struct {
+	// SMTPHost holds a host of the SMTP server. Digests are disabled if it is empty.
+	SMTPHost     string `yaml:"smtp_host"`
+	SMTPPort     int    `yaml:"smtp_port"`
+	SMTPUsername string `yaml:"smtp_username"`
+	SMTPPassword string `yaml:"smtp_password"`
+
+	// SMTPTLS holds how the connection to the SMTP server is secured: "none",
+	// "starttls" or "tls".
+	SMTPTLS string `yaml:"smtp_tls"`
+
+	BreakIDs []int `yaml:"break_ids"`
+
+	// Time holds the time of day a digest is sent at in the "15:04" format.
+	Time string `yaml:"time"`
+
+	// Timezone holds the IANA name of the time zone Time is in (i.e. "Europe/Lisbon").
+	Timezone string `yaml:"timezone"`
+
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+}
+
+// Health holds settings of the readiness checks.
+type Health struct {
+	// CanaryBreakID holds an ID of a reference surf break whose forecast is scraped
+	// periodically to check that www.surf-forecast.com can be scraped. The check is
+	// skipped if it is zero.
+	CanaryBreakID int `yaml:"canary_break_id"`
+
+	// CanaryInterval holds how often the reference surf break is scraped.
+	CanaryInterval time.Duration `yaml:"canary_interval"`
+}
+
+// Default returns the default configuration.
+func Default() Config {
+	return Config{
+		ListenAddr: ":8080",
+		LogLevel:   "info",
+		LogFormat:  "text",
+		PublicURL:  "http://localhost:8080",
+		Server: Server{
+			ReadHeaderTimeout: 5 * time.Second,
+			ReadTimeout:       15 * time.Second,
+			// Some pages scrape several pages of www.surf-forecast.com in a row.
+			WriteTimeout:    time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+			HSTS:            true,
+		},
+		Scraper: Scraper{
+			BaseURL:          "https://www.surf-forecast.com",
+			Timeout:          10 * time.Second,
+			MaxRetries:       3,
+			CircuitThreshold: 5,
+			CircuitCooldown:  30 * time.Second,
+			RateLimit:        2,
+			RateBurst:        5,
+			MaxConcurrency:   4,
+			MaxQueue:         32,
+			UserAgent:        "glassy (+https://github.com/ztimes2/glassy)",
+		},
+		Cache: Cache{
+			MaxAge: time.Hour,
+			TTL:    time.Hour,
+		},
+		Storage: Storage{
+			DataDir:           "data",
+			ForecastRetention: 90 * 24 * time.Hour,
+		},
+		Alerts: Alerts{
+			Interval: time.Hour,
+		},
+		Digest: Digest{
+			SMTPPort: 587,
+			SMTPTLS:  "starttls",
+			Time:     "06:00",
+			Timezone: "UTC",
+		},
+		Health: Health{
+			CanaryInterval: 5 * time.Minute,
+		},
+	}
+}
+
+// Load loads the configuration from the given command-line arguments, the environment
+// variables and an optional YAML file, in that order of precedence, on top of the
+// defaults. The file is read from the path given by the -config flag or the
+// GLASSY_CONFIG environment variable. The loaded configuration is validated.
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("glassy", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	var (
+		path        = fs.String("config", os.Getenv("GLASSY_CONFIG"), "path to a yaml config file")
+		listenAddr  = fs.String("listen", "", "address the web server listens on")
+		logLevel    = fs.String("log-level", "", "minimum level of logged messages")
+		logFormat   = fs.String("log-format", "", "format of logged messages")
+		shutdown    = fs.Duration("shutdown-timeout", 0, "how long shutdown waits for in-flight work")
+		baseURL     = fs.String("base-url", "", "base url of www.surf-forecast.com")
+		timeout     = fs.Duration("timeout", 0, "timeout of requests to www.surf-forecast.com")
+		cacheMaxAge = fs.Duration("cache-max-age", 0, "how long clients may cache responses")
+		cacheTTL    = fs.Duration("cache-ttl", 0, "how long scraped data is reused")
+		dataDir     = fs.String("data-dir", "", "directory where data is persisted")
+	)
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	if fs.NArg() > 0 {
+		return Config{}, fmt.Errorf("unexpected arguments: %q", fs.Args())
+	}
+
+	if *path != "" {
+		if err := cfg.readFile(*path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.readEnv(); err != nil {
+		return Config{}, err
+	}
+
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "listen":
+			cfg.ListenAddr = *listenAddr
+		case "log-level":
+			cfg.LogLevel = *logLevel
+		case "log-format":
+			cfg.LogFormat = *logFormat
+		case "shutdown-timeout":
+			cfg.Server.ShutdownTimeout = *shutdown
+		case "base-url":
+			cfg.Scraper.BaseURL = *baseURL
+		case "timeout":
+			cfg.Scraper.Timeout = *timeout
+		case "cache-max-age":
+			cfg.Cache.MaxAge = *cacheMaxAge
+		case "cache-ttl":
+			cfg.Cache.TTL = *cacheTTL
+		case "data-dir":
+			cfg.Storage.DataDir = *dataDir
+		}
+	})
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("invalid config: %w", err)
+	}
+
+	return cfg, nil
+}
+
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(c); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("could not decode config file: %w", err)
+	}
+
+	return nil
+}
+
+func (c *Config) readEnv() error {
+	var err error
+	env := func(key string, fn func(string) error) {
+		if err != nil {
+			return
+		}
+		if v, ok := os.LookupEnv(key); ok {
+			if e := fn(strings.TrimSpace(v)); e != nil {
+				err = fmt.Errorf("invalid %s: %w", key, e)
+			}
+		}
+	}
+
+	env("GLASSY_LISTEN_ADDR", setString(&c.ListenAddr))
+	env("GLASSY_LOG_LEVEL", setString(&c.LogLevel))
+	env("GLASSY_LOG_FORMAT", setString(&c.LogFormat))
+	env("GLASSY_PUBLIC_URL", setString(&c.PublicURL))
+	env("GLASSY_READ_HEADER_TIMEOUT", setDuration(&c.Server.ReadHeaderTimeout))
+	env("GLASSY_READ_TIMEOUT", setDuration(&c.Server.ReadTimeout))
+	env("GLASSY_WRITE_TIMEOUT", setDuration(&c.Server.WriteTimeout))
+	env("GLASSY_IDLE_TIMEOUT", setDuration(&c.Server.IdleTimeout))
+	env("GLASSY_SHUTDOWN_TIMEOUT", setDuration(&c.Server.ShutdownTimeout))
+	env("GLASSY_HSTS", setBool(&c.Server.HSTS))
+	env("GLASSY_BASE_URL", setString(&c.Scraper.BaseURL))
+	env("GLASSY_TIMEOUT", setDuration(&c.Scraper.Timeout))
+	env("GLASSY_MAX_RETRIES", setInt(&c.Scraper.MaxRetries))
+	env("GLASSY_CIRCUIT_THRESHOLD", setInt(&c.Scraper.CircuitThreshold))
+	env("GLASSY_CIRCUIT_COOLDOWN", setDuration(&c.Scraper.CircuitCooldown))
+	env("GLASSY_RATE_LIMIT", setFloat(&c.Scraper.RateLimit))
+	env("GLASSY_RATE_BURST", setInt(&c.Scraper.RateBurst))
+	env("GLASSY_MAX_CONCURRENCY", setInt(&c.Scraper.MaxConcurrency))
+	env("GLASSY_MAX_QUEUE", setInt(&c.Scraper.MaxQueue))
+	env("GLASSY_USER_AGENT", setString(&c.Scraper.UserAgent))
+	env("GLASSY_RESPECT_ROBOTS", setBool(&c.Scraper.RespectRobots))
+	env("GLASSY_CACHE_MAX_AGE", setDuration(&c.Cache.MaxAge))
+	env("GLASSY_CACHE_TTL", setDuration(&c.Cache.TTL))
+	env("GLASSY_DATA_DIR", setString(&c.Storage.DataDir))
+	env("GLASSY_FORECAST_RETENTION", setDuration(&c.Storage.ForecastRetention))
+	env("GLASSY_ALERT_INTERVAL", setDuration(&c.Alerts.Interval))
+	env("GLASSY_ALERT_API_TOKEN", setString(&c.Alerts.APIToken))
+	env("GLASSY_WEBHOOK_URLS", setStrings(&c.Alerts.WebhookURLs))
+	env("GLASSY_WEBHOOK_SECRET", setString(&c.Alerts.WebhookSecret))
+	env("GLASSY_EXPOSE_WEBHOOK_DELIVERIES", setBool(&c.Alerts.ExposeDeliveries))
+	env("GLASSY_SMTP_HOST", setString(&c.Digest.SMTPHost))
+	env("GLASSY_SMTP_PORT", setInt(&c.Digest.SMTPPort))
+	env("GLASSY_SMTP_USERNAME", setString(&c.Digest.SMTPUsername))
+	env("GLASSY_SMTP_PASSWORD", setString(&c.Digest.SMTPPassword))
+	env("GLASSY_SMTP_TLS", setString(&c.Digest.SMTPTLS))
+	env("GLASSY_DIGEST_BREAK_IDS", setInts(&c.Digest.BreakIDs))
+	env("GLASSY_DIGEST_TIME", setString(&c.Digest.Time))
+	env("GLASSY_DIGEST_TIMEZONE", setString(&c.Digest.Timezone))
+	env("GLASSY_DIGEST_FROM", setString(&c.Digest.From))
+	env("GLASSY_DIGEST_TO", setStrings(&c.Digest.To))
+	env("GLASSY_CANARY_BREAK_ID", setInt(&c.Health.CanaryBreakID))
+	env("GLASSY_CANARY_INTERVAL", setDuration(&c.Health.CanaryInterval))
+
+	return err
+}
+
+func setString(p *string) func(string) error {
+	return func(s string) error {
+		*p = s
+		return nil
+	}
+}
+
+func setStrings(p *[]string) func(string) error {
+	return func(s string) error {
+		*p = splitList(s)
+		return nil
+	}
+}
+
+func setInt(p *int) func(string) error {
+	return func(s string) error {
+		i, err := strconv.Atoi(s)
+		if err != nil {
+			return err
+		}
+		*p = i
+		return nil
+	}
+}
+
+func setFloat(p *float64) func(string) error {
+	return func(s string) error {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		*p = f
+		return nil
+	}
+}
+
+func setBool(p *bool) func(string) error {
+	return func(s string) error {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return err
+		}
+		*p = b
+		return nil
+	}
+}
+
+func setInts(p *[]int) func(string) error {
+	return func(s string) error {
+		var ints []int
+		for _, v := range splitList(s) {
+			i, err := strconv.Atoi(v)
+			if err != nil {
+				return err
+			}
+			ints = append(ints, i)
+		}
+		*p = ints
+		return nil
+	}
+}
+
+func setDuration(p *time.Duration) func(string) error {
+	return func(s string) error {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*p = d
+		return nil
+	}
+}
+
+// splitList splits a comma-separated list skipping blank elements.
+func splitList(s string) []string {
+	var list []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
+
+// Validate checks if the configuration is valid.
+func (c Config) Validate() error {
+	if c.ListenAddr == "" {
+		return errors.New("listen address must not be empty")
+	}
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.LogLevel)); err != nil {
+		return fmt.Errorf("log level must be one of debug, info, warn or error: %q", c.LogLevel)
+	}
+
+	switch c.LogFormat {
+	case "text", "json":
+	default:
+		return fmt.Errorf("log format must be either text or json: %q", c.LogFormat)
+	}
+
+	if u, err := url.Parse(c.PublicURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("public url must be an absolute http or https url: %q", c.PublicURL)
+	}
+
+	if c.Server.ReadHeaderTimeout <= 0 ||
+		c.Server.ReadTimeout <= 0 ||
+		c.Server.WriteTimeout <= 0 ||
+		c.Server.IdleTimeout <= 0 {
+
+		return errors.New("server timeouts must be positive")
+	}
+	if c.Server.ShutdownTimeout <= 0 {
+		return errors.New("shutdown timeout must be positive")
+	}
+
+	if u, err := url.Parse(c.Scraper.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+		return fmt.Errorf("scraper base url must be an absolute url: %q", c.Scraper.BaseURL)
+	}
+	if c.Scraper.Timeout <= 0 {
+		return errors.New("scraper timeout must be positive")
+	}
+	if c.Scraper.MaxRetries < 0 {
+		return errors.New("scraper max retries must not be negative")
+	}
+	if c.Scraper.CircuitThreshold <= 0 {
+		return errors.New("circuit threshold must be positive")
+	}
+	if c.Scraper.CircuitCooldown <= 0 {
+		return errors.New("circuit cooldown must be positive")
+	}
+	if c.Scraper.RateLimit <= 0 {
+		return errors.New("scraper rate limit must be positive")
+	}
+	if c.Scraper.RateBurst <= 0 {
+		return errors.New("scraper rate burst must be positive")
+	}
+	if c.Scraper.MaxConcurrency <= 0 {
+		return errors.New("scraper max concurrency must be positive")
+	}
+	if c.Scraper.MaxQueue <= 0 {
+		return errors.New("scraper max queue must be positive")
+	}
+	if strings.TrimSpace(c.Scraper.UserAgent) == "" {
+		return errors.New("scraper user agent must not be blank")
+	}
+
+	if c.Cache.MaxAge < 0 {
+		return errors.New("cache max age must not be negative")
+	}
+	if c.Cache.TTL <= 0 {
+		return errors.New("cache ttl must be positive")
+	}
+
+	if c.Storage.DataDir == "" {
+		return errors.New("data directory must not be empty")
+	}
+	if c.Storage.ForecastRetention <= 0 {
+		return errors.New("forecast retention must be positive")
+	}
+
+	if c.Alerts.Interval <= 0 {
+		return errors.New("alert interval must be positive")
+	}
+	for _, u := range c.Alerts.WebhookURLs {
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("webhook url must be an absolute http or https url: %q", webhook.RedactURL(u))
+		}
+	}
+	if len(c.Alerts.WebhookURLs) > 0 && c.Alerts.WebhookSecret == "" {
+		return errors.New("webhook secret must not be empty if webhook urls are set")
+	}
+
+	if c.Health.CanaryBreakID < 0 {
+		return errors.New("canary break id must not be negative")
+	}
+	if c.Health.CanaryInterval <= 0 {
+		return errors.New("canary interval must be positive")
+	}
+
+	if c.Digest.SMTPHost != "" {
+		if c.Digest.SMTPPort <= 0 || c.Digest.SMTPPort > 65535 {
+			return fmt.Errorf("invalid smtp port: %d", c.Digest.SMTPPort)
+		}
+		if _, err := digest.ParseTLSMode(c.Digest.SMTPTLS); err != nil {
+			return fmt.Errorf("smtp tls must be one of none, starttls or tls: %q", c.Digest.SMTPTLS)
+		}
+		if len(c.Digest.BreakIDs) == 0 {
+			return errors.New("digest break ids must not be empty")
+		}
+		if _, err := c.Digest.TimeOfDay(); err != nil {
+			return err
+		}
+		if _, err := c.Digest.Location(); err != nil {
+			return err
+		}
+		if c.Digest.From == "" {
+			return errors.New("digest sender must not be empty")
+		}
+		if len(c.Digest.To) == 0 {
+			return errors.New("digest recipients must not be empty")
+		}
+	}
+
+	return nil
+}
+
+// TimeOfDay returns the time of day a digest is sent at as a duration since midnight.
+func (d Digest) TimeOfDay() (time.Duration, error) {
+	t, err := time.Parse("15:04", d.Time)
+	if err != nil {
+		return 0, fmt.Errorf("invalid digest time: %q", d.Time)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+// Location returns the time zone a digest is sent in.
+func (d Digest) Location() (*time.Location, error) {
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid digest timezone: %q", d.Timezone)
+	}
+	return loc, nil
+}
+
+// NewLogger initializes a new logger that writes messages of the configured level
+// and format to the given writer.
+func (c Config) NewLogger(w io.Writer) *slog.Logger {
+	var level slog.Level
+	_ = level.UnmarshalText([]byte(c.LogLevel))
+
+	opts := &slog.HandlerOptions{Level: level}
+	if c.LogFormat == "json" {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// redacted is the placeholder of secrets in the printed configuration.
+const redacted = "[redacted]"
+
+// LogValue implements slog.LogValuer. Secrets and webhook URLs are redacted, and
+// durations are logged as strings to keep them readable in JSON.
+func (c Config) LogValue() slog.Value {
+	secret := func(s string) string {
+		if s == "" {
+			return ""
+		}
+		return redacted
+	}
+
+	webhookURLs := make([]string, len(c.Alerts.WebhookURLs))
+	for i, u := range c.Alerts.WebhookURLs {
+		webhookURLs[i] = webhook.RedactURL(u)
+	}
+
+	return slog.GroupValue(
+		slog.String("listen_addr", c.ListenAddr),
+		slog.String("log_level", c.LogLevel),
+		slog.String("log_format", c.LogFormat),
+		slog.String("public_url", c.PublicURL),
+		slog.Group(
+			"server",
+			slog.String("read_header_timeout", c.Server.ReadHeaderTimeout.String()),
+			slog.String("read_timeout", c.Server.ReadTimeout.String()),
+			slog.String("write_timeout", c.Server.WriteTimeout.String()),
+			slog.String("idle_timeout", c.Server.IdleTimeout.String()),
+			slog.String("shutdown_timeout", c.Server.ShutdownTimeout.String()),
+			slog.Bool("hsts", c.Server.HSTS),
+		),
+		slog.Group(
+			"scraper",
+			slog.String("base_url", c.Scraper.BaseURL),
+			slog.String("timeout", c.Scraper.Timeout.String()),
+			slog.Int("max_retries", c.Scraper.MaxRetries),
+			slog.Int("circuit_threshold", c.Scraper.CircuitThreshold),
+			slog.String("circuit_cooldown", c.Scraper.CircuitCooldown.String()),
+			slog.Float64("rate_limit", c.Scraper.RateLimit),
+			slog.Int("rate_burst", c.Scraper.RateBurst),
+			slog.Int("max_concurrency", c.Scraper.MaxConcurrency),
+			slog.Int("max_queue", c.Scraper.MaxQueue),
+			slog.String("user_agent", c.Scraper.UserAgent),
+			slog.Bool("respect_robots", c.Scraper.RespectRobots),
+		),
+		slog.Group(
+			"cache",
+			slog.String("max_age", c.Cache.MaxAge.String()),
+			slog.String("ttl", c.Cache.TTL.String()),
+		),
+		slog.Group(
+			"storage",
+			slog.String("data_dir", c.Storage.DataDir),
+			slog.String("forecast_retention", c.Storage.ForecastRetention.String()),
+		),
+		slog.Group(
+			"alerts",
+			slog.String("interval", c.Alerts.Interval.String()),
+			slog.String("api_token", secret(c.Alerts.APIToken)),
+			slog.Any("webhook_urls", webhookURLs),
+			slog.String("webhook_secret", secret(c.Alerts.WebhookSecret)),
+			slog.Bool("expose_deliveries", c.Alerts.ExposeDeliveries),
+		),
+		slog.Group(
+			"digest",
+			slog.String("smtp_host", c.Digest.SMTPHost),
+			slog.Int("smtp_port", c.Digest.SMTPPort),
+			slog.String("smtp_username", c.Digest.SMTPUsername),
+			slog.String("smtp_password", secret(c.Digest.SMTPPassword)),
+			slog.String("smtp_tls", c.Digest.SMTPTLS),
+			slog.Any("break_ids", c.Digest.BreakIDs),
+			slog.String("time", c.Digest.Time),
+			slog.String("timezone", c.Digest.Timezone),
+			slog.String("from", c.Digest.From),
+			slog.Any("to", c.Digest.To),
+		),
+		slog.Group(
+			"health",
+			slog.Int("canary_break_id", c.Health.CanaryBreakID),
+			slog.String("canary_interval", c.Health.CanaryInterval.String()),
+		),
+	)
+}
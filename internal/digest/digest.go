@@ -0,0 +1,220 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/surf"
+	"github.com/ztimes2/glassy/internal/ui"
+)
+
+// daysPerBreak is the number of upcoming days of each surf break included in a digest.
+const daysPerBreak = 3
+
+// sendTimeout is the time limit of emailing a digest, so that a stalled SMTP server
+// does not hold up the following digests.
+const sendTimeout = time.Minute
+
+// Sender composes daily digests of the selected surf breaks' forecasts and emails
+// them once a day.
+type Sender struct {
+	service    *surf.Service
+	mailer     *Mailer
+	breakIDs   []int
+	recipients []string
+	baseURL    string
+	at         time.Duration
+	location   *time.Location
+	onError    func(error)
+}
+
+// NewSender initializes a new Sender that emails a digest of the given surf breaks to
+// the given recipients every day at the given time of day in the given location. The
+// base URL is used for linking to the application. Errors that occur in the background
+// are passed to onError which can be nil.
+func NewSender(
+	service *surf.Service,
+	mailer *Mailer,
+	breakIDs []int,
+	recipients []string,
+	baseURL string,
+	at time.Duration,
+	location *time.Location,
+	onError func(error),
+) *Sender {
+
+	if onError == nil {
+		onError = func(error) {}
+	}
+
+	return &Sender{
+		service:    service,
+		mailer:     mailer,
+		breakIDs:   breakIDs,
+		recipients: recipients,
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		at:         at,
+		location:   location,
+		onError:    onError,
+	}
+}
+
+// Run sends a digest every day at the configured time until the given context is
+// canceled.
+func (s *Sender) Run(ctx context.Context) {
+	for {
+		t := time.NewTimer(time.Until(s.nextRun(time.Now())))
+
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		if err := s.Send(ctx); err != nil {
+			s.onError(err)
+		}
+	}
+}
+
+// nextRun returns the earliest time after now when a digest is due.
+func (s *Sender) nextRun(now time.Time) time.Time {
+	now = now.In(s.location)
+
+	y, m, d := now.Date()
+	next := time.Date(y, m, d, 0, 0, 0, 0, s.location).Add(s.at)
+	if !next.After(now) {
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, s.location).Add(s.at)
+	}
+	return next
+}
+
+// Send composes and emails a digest right away. Surf breaks whose forecasts cannot be
+// fetched are shown as unavailable, and their errors are returned once the digest has
+// been sent.
+func (s *Sender) Send(ctx context.Context) error {
+	props := ui.DigestEmailProps{
+		Date:    time.Now().In(s.location),
+		BaseURL: s.baseURL,
+	}
+
+	var errs []error
+	for _, id := range s.breakIDs {
+		db, err := s.digestBreak(id, props.Date)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		props.Breaks = append(props.Breaks, db)
+	}
+
+	html := new(bytes.Buffer)
+	if err := ui.DigestEmail(props).Render(html); err != nil {
+		return fmt.Errorf("could not render html: %w", err)
+	}
+
+	subject := "Surf digest for " + props.Date.Format("Monday, 2 January")
+
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+
+	if err := s.mailer.Send(ctx, s.recipients, subject, text(props), html.String()); err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// digestBreak returns the upcoming daily forecasts of a surf break starting from the
+// given date. If they cannot be fetched, the surf break is returned as unavailable
+// along with the error.
+func (s *Sender) digestBreak(id int, date time.Time) (ui.DigestBreak, error) {
+	brk, err := s.service.Break(id)
+	if err != nil {
+		return ui.DigestBreak{
+			Break:       meteo365.Break{ID: id, Name: "Surf break " + strconv.Itoa(id)},
+			Unavailable: true,
+		}, fmt.Errorf("could not fetch surf break %d: %w", id, err)
+	}
+
+	iss, err := s.service.LatestForecastIssue(brk)
+	if err != nil {
+		return ui.DigestBreak{
+			Break:       brk,
+			Unavailable: true,
+		}, fmt.Errorf("could not fetch forecast of surf break %d: %w", id, err)
+	}
+
+	return ui.DigestBreak{
+		Break: brk,
+		Days:  upcomingDays(iss, date),
+	}, nil
+}
+
+// upcomingDays returns the daily forecasts starting from the given date.
+func upcomingDays(iss *meteo365.ForecastIssue, date time.Time) []*meteo365.DailyForecast {
+	y, m, d := date.Date()
+
+	var days []*meteo365.DailyForecast
+	for _, df := range iss.Daily {
+		if len(days) == daysPerBreak {
+			break
+		}
+
+		dy, dm, dd := df.Timestamp.Date()
+		if time.Date(dy, dm, dd, 0, 0, 0, 0, time.UTC).Before(time.Date(y, m, d, 0, 0, 0, 0, time.UTC)) {
+			continue
+		}
+
+		days = append(days, df)
+	}
+	return days
+}
+
+// text renders the plain text alternative of a digest.
+func text(props ui.DigestEmailProps) string {
+	var b strings.Builder
+
+	b.WriteString("Surf digest for " + props.Date.Format("Monday, 2 January") + "\n")
+
+	for _, db := range props.Breaks {
+		b.WriteString("\n" + db.Break.Name + ", " + db.Break.CountryName + "\n")
+		b.WriteString(props.BaseURL + "/breaks/" + strconv.Itoa(db.Break.ID) + "/forecasts/latest\n")
+
+		if db.Unavailable {
+			b.WriteString("  The forecast is unavailable at the moment.\n")
+			continue
+		}
+
+		for _, df := range db.Days {
+			s := df.Summary()
+			fmt.Fprintf(
+				&b,
+				"  %-10s %s–%s m, %s s, %s km/h %s, %d/10\n",
+				df.Timestamp.Format("Mon 2 Jan"),
+				formatFloat(s.MinWaveHeightInMeters),
+				formatFloat(s.MaxWaveHeightInMeters),
+				formatFloat(s.MaxPeriodInSeconds),
+				formatFloat(s.MaxWindSpeedInKilometersPerHour),
+				s.PrevailingWindState,
+				s.MaxRating,
+			)
+		}
+	}
+
+	b.WriteString("\nThe location and forecast data is obtained from www.surf-forecast.com via web scraping, it belongs to its original creators, and full credit is given to them.\n")
+
+	return b.String()
+}
+
+// formatFloat returns a textual representation of a float rounded to one decimal place.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
@@ -0,0 +1,216 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TLSMode describes how a connection to an SMTP server is secured.
+type TLSMode string
+
+const (
+	// TLSModeNone sends emails over a plain connection.
+	TLSModeNone TLSMode = "none"
+
+	// TLSModeStartTLS upgrades a plain connection using the STARTTLS command.
+	TLSModeStartTLS TLSMode = "starttls"
+
+	// TLSModeImplicit establishes a TLS connection right away (i.e. on port 465).
+	TLSModeImplicit TLSMode = "tls"
+)
+
+// ParseTLSMode parses a textual representation of TLSMode.
+func ParseTLSMode(s string) (TLSMode, error) {
+	switch m := TLSMode(strings.ToLower(s)); m {
+	case TLSModeNone, TLSModeStartTLS, TLSModeImplicit:
+		return m, nil
+	default:
+		return "", fmt.Errorf("invalid tls mode: %q", s)
+	}
+}
+
+// SMTPConfig holds settings of an SMTP server.
+type SMTPConfig struct {
+	Host string
+	Port int
+
+	// Username and Password are used for PLAIN authentication. Authentication is
+	// skipped if Username is empty.
+	Username string
+	Password string
+
+	TLS  TLSMode
+	From string
+}
+
+// Mailer sends emails over SMTP.
+type Mailer struct {
+	config SMTPConfig
+}
+
+// NewMailer initializes a new Mailer.
+func NewMailer(config SMTPConfig) *Mailer {
+	return &Mailer{
+		config: config,
+	}
+}
+
+// Send sends an email with plain text and HTML alternatives of the same content.
+func (m *Mailer) Send(ctx context.Context, to []string, subject, text, html string) error {
+	msg, err := m.message(to, subject, text, html)
+	if err != nil {
+		return fmt.Errorf("could not compose message: %w", err)
+	}
+
+	addr := net.JoinHostPort(m.config.Host, strconv.Itoa(m.config.Port))
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("could not connect: %w", err)
+	}
+	defer conn.Close()
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	if m.config.TLS == TLSModeImplicit {
+		conn = tls.Client(conn, &tls.Config{ServerName: m.config.Host})
+	}
+
+	c, err := smtp.NewClient(conn, m.config.Host)
+	if err != nil {
+		return fmt.Errorf("could not start session: %w", err)
+	}
+	defer c.Close()
+
+	if m.config.TLS == TLSModeStartTLS {
+		if err := c.StartTLS(&tls.Config{ServerName: m.config.Host}); err != nil {
+			return fmt.Errorf("could not start tls: %w", err)
+		}
+	}
+
+	if m.config.Username != "" {
+		auth := smtp.PlainAuth("", m.config.Username, m.config.Password, m.config.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("could not authenticate: %w", err)
+		}
+	}
+
+	from, err := mail.ParseAddress(m.config.From)
+	if err != nil {
+		return fmt.Errorf("invalid sender: %w", err)
+	}
+
+	if err := c.Mail(from.Address); err != nil {
+		return fmt.Errorf("could not set sender: %w", err)
+	}
+
+	for _, rcpt := range to {
+		addr, err := mail.ParseAddress(rcpt)
+		if err != nil {
+			return fmt.Errorf("invalid recipient: %w", err)
+		}
+		if err := c.Rcpt(addr.Address); err != nil {
+			return fmt.Errorf("could not set recipient %s: %w", addr.Address, err)
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("could not start data: %w", err)
+	}
+
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("could not write data: %w", err)
+	}
+
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("could not finish data: %w", err)
+	}
+
+	return c.Quit()
+}
+
+// message composes a MIME message with multipart/alternative body.
+func (m *Mailer) message(to []string, subject, text, html string) ([]byte, error) {
+	if len(to) == 0 {
+		return nil, errors.New("no recipients")
+	}
+
+	id, err := messageID()
+	if err != nil {
+		return nil, fmt.Errorf("could not generate message id: %w", err)
+	}
+
+	var (
+		buf = new(bytes.Buffer)
+		mw  = multipart.NewWriter(buf)
+	)
+
+	header := []string{
+		"From: " + m.config.From,
+		"To: " + strings.Join(to, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: <" + id + "@glassy>",
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + mw.Boundary(),
+	}
+	buf.WriteString(strings.Join(header, "\r\n") + "\r\n\r\n")
+
+	parts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	}
+	for _, p := range parts {
+		pw, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create part: %w", err)
+		}
+
+		qw := quotedprintable.NewWriter(pw)
+		if _, err := qw.Write([]byte(p.body)); err != nil {
+			return nil, fmt.Errorf("could not write part: %w", err)
+		}
+		if err := qw.Close(); err != nil {
+			return nil, fmt.Errorf("could not finish part: %w", err)
+		}
+	}
+
+	if err := mw.Close(); err != nil {
+		return nil, fmt.Errorf("could not finish message: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func messageID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,296 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// smtpSink is a local SMTP server that accepts every email and keeps what it was
+// sent for inspection.
+type smtpSink struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	auth  string
+	from  string
+	rcpts []string
+	data  string
+	done  chan struct{}
+}
+
+func newSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+
+	s := &smtpSink{
+		ln:   ln,
+		done: make(chan struct{}),
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	go s.serve()
+	return s
+}
+
+func (s *smtpSink) port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+func (s *smtpSink) serve() {
+	defer close(s.done)
+
+	conn, err := s.ln.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	tc := textproto.NewConn(conn)
+	_ = tc.PrintfLine("220 localhost ESMTP sink")
+
+	for {
+		line, err := tc.ReadLine()
+		if err != nil {
+			return
+		}
+
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			_ = tc.PrintfLine("250-localhost")
+			_ = tc.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			s.mu.Lock()
+			s.auth = arg
+			s.mu.Unlock()
+			_ = tc.PrintfLine("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.mu.Lock()
+			s.from = arg
+			s.mu.Unlock()
+			_ = tc.PrintfLine("250 OK")
+		case "RCPT":
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, arg)
+			s.mu.Unlock()
+			_ = tc.PrintfLine("250 OK")
+		case "DATA":
+			_ = tc.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := tc.ReadDotBytes()
+			if err != nil {
+				return
+			}
+			s.mu.Lock()
+			s.data = string(data)
+			s.mu.Unlock()
+			_ = tc.PrintfLine("250 OK")
+		case "QUIT":
+			_ = tc.PrintfLine("221 Bye")
+			return
+		default:
+			_ = tc.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func TestMailer_Send(t *testing.T) {
+	sink := newSMTPSink(t)
+
+	m := NewMailer(SMTPConfig{
+		Host:     "127.0.0.1",
+		Port:     sink.port(),
+		Username: "user",
+		Password: "pass",
+		TLS:      TLSModeNone,
+		From:     "Glassy <glassy@example.com>",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var (
+		to      = []string{"Surfer <surfer@example.com>", "crew@example.com"}
+		subject = "Surf digest – Ericeira"
+		text    = "Supertubos: Chest high, 11 s, offshore\nLine that is long enough to be wrapped by the quoted-printable encoding of the body."
+		html    = `<p style="color: #333">Supertubos: Chest high, 11 s, offshore</p>`
+	)
+	if err := m.Send(ctx, to, subject, text, html); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	<-sink.done
+
+	sink.mu.Lock()
+	defer sink.mu.Unlock()
+
+	if want := "PLAIN " + base64.StdEncoding.EncodeToString([]byte("\x00user\x00pass")); sink.auth != want {
+		t.Errorf("unexpected auth: %q", sink.auth)
+	}
+	if sink.from != "FROM:<glassy@example.com>" {
+		t.Errorf("unexpected sender: %q", sink.from)
+	}
+	if want := []string{"TO:<surfer@example.com>", "TO:<crew@example.com>"}; strings.Join(sink.rcpts, ",") != strings.Join(want, ",") {
+		t.Errorf("unexpected recipients: %q", sink.rcpts)
+	}
+
+	msg, err := mail.ReadMessage(strings.NewReader(sink.data))
+	if err != nil {
+		t.Fatalf("could not read message: %v", err)
+	}
+
+	if got := msg.Header.Get("From"); got != "Glassy <glassy@example.com>" {
+		t.Errorf("unexpected From: %q", got)
+	}
+	if got := msg.Header.Get("To"); got != strings.Join(to, ", ") {
+		t.Errorf("unexpected To: %q", got)
+	}
+	if got, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); err != nil || got != subject {
+		t.Errorf("unexpected Subject: %q (%v)", got, err)
+	}
+	if got := msg.Header.Get("MIME-Version"); got != "1.0" {
+		t.Errorf("unexpected MIME-Version: %q", got)
+	}
+	if _, err := msg.Header.Date(); err != nil {
+		t.Errorf("invalid Date: %v", err)
+	}
+	if id := msg.Header.Get("Message-ID"); !strings.HasPrefix(id, "<") || !strings.HasSuffix(id, "@glassy>") {
+		t.Errorf("unexpected Message-ID: %q", id)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("unexpected Content-Type: %q", msg.Header.Get("Content-Type"))
+	}
+
+	wantParts := []struct {
+		contentType string
+		body        string
+	}{
+		{"text/plain; charset=utf-8", text},
+		{"text/html; charset=utf-8", html},
+	}
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	for i, want := range wantParts {
+		p, err := mr.NextRawPart()
+		if err != nil {
+			t.Fatalf("part %d: could not read: %v", i, err)
+		}
+
+		if got := p.Header.Get("Content-Type"); got != want.contentType {
+			t.Errorf("part %d: unexpected Content-Type: %q", i, got)
+		}
+		if got := p.Header.Get("Content-Transfer-Encoding"); got != "quoted-printable" {
+			t.Errorf("part %d: unexpected Content-Transfer-Encoding: %q", i, got)
+		}
+
+		raw, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("part %d: could not read body: %v", i, err)
+		}
+		// ReadDotBytes turns line endings into "\n".
+		for _, line := range strings.Split(string(raw), "\n") {
+			if len(line) > 76 {
+				t.Errorf("part %d: line longer than 76 characters: %q", i, line)
+			}
+		}
+
+		body, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(string(raw))))
+		if err != nil {
+			t.Fatalf("part %d: could not decode body: %v", i, err)
+		}
+		if string(body) != want.body {
+			t.Errorf("part %d: unexpected body: %q", i, body)
+		}
+	}
+
+	if _, err := mr.NextPart(); err != io.EOF {
+		t.Errorf("expected no more parts, got %v", err)
+	}
+}
+
+func TestMailer_SendWithoutRecipients(t *testing.T) {
+	m := NewMailer(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: 1,
+		TLS:  TLSModeNone,
+		From: "glassy@example.com",
+	})
+
+	if err := m.Send(context.Background(), nil, "subject", "text", "html"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestMailer_SendRejected(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not listen: %v", err)
+	}
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		w := bufio.NewWriter(conn)
+		_, _ = w.WriteString("554 No SMTP service here\r\n")
+		_ = w.Flush()
+	}()
+
+	m := NewMailer(SMTPConfig{
+		Host: "127.0.0.1",
+		Port: ln.Addr().(*net.TCPAddr).Port,
+		TLS:  TLSModeNone,
+		From: "glassy@example.com",
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := m.Send(ctx, []string{"surfer@example.com"}, "subject", "text", "html"); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestParseTLSMode(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    TLSMode
+		wantErr bool
+	}{
+		{s: "none", want: TLSModeNone},
+		{s: "starttls", want: TLSModeStartTLS},
+		{s: "STARTTLS", want: TLSModeStartTLS},
+		{s: "tls", want: TLSModeImplicit},
+		{s: "ssl", wantErr: true},
+		{s: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseTLSMode(tt.s)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseTLSMode(%s): got %q, %v", strconv.Quote(tt.s), got, err)
+		}
+	}
+}
@@ -0,0 +1,171 @@
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// Row holds a flattened hourly forecast.
+type Row struct {
+	// Timestamp holds the hourly forecast's timestamp in the surf break's local time
+	// zone, which is encoded with its UTC offset.
+	Timestamp              time.Time `json:"timestamp"`
+	Rating                 int       `json:"rating"`
+	PrimarySwell           Swell     `json:"primary_swell"`
+	SecondarySwells        []Swell   `json:"secondary_swells"`
+	WaveEnergyInKiloJoules float64   `json:"wave_energy_kj"`
+	Wind                   Wind      `json:"wind"`
+}
+
+// Swell holds a flattened swell train.
+type Swell struct {
+	WaveHeightInMeters           float64 `json:"wave_height_m"`
+	PeriodInSeconds              float64 `json:"period_s"`
+	DirectionToInDegrees         float64 `json:"direction_to_deg"`
+	DirectionFromInCompassPoints string  `json:"direction_from"`
+}
+
+// Wind holds a flattened wind.
+type Wind struct {
+	SpeedInKilometersPerHour     float64 `json:"speed_kmh"`
+	DirectionToInDegrees         float64 `json:"direction_to_deg"`
+	DirectionFromInCompassPoints string  `json:"direction_from"`
+	State                        string  `json:"state"`
+}
+
+// Rows flattens every hourly forecast of a forecast issue into a Row.
+func Rows(iss *meteo365.ForecastIssue) []Row {
+	var rows []Row
+	for _, df := range iss.Daily {
+		for _, hf := range df.Hourly {
+			row := Row{
+				Timestamp:              hf.Timestamp,
+				Rating:                 hf.Rating,
+				PrimarySwell:           newSwell(hf.Swells.Primary),
+				SecondarySwells:        make([]Swell, 0, len(hf.Swells.Secondary)),
+				WaveEnergyInKiloJoules: hf.WaveEnergyInKiloJoules,
+				Wind: Wind{
+					SpeedInKilometersPerHour:     hf.Wind.SpeedInKilometersPerHour,
+					DirectionToInDegrees:         hf.Wind.DirectionToInDegrees,
+					DirectionFromInCompassPoints: hf.Wind.DirectionFromInCompassPoints,
+					State:                        hf.Wind.State,
+				},
+			}
+			for _, s := range hf.Swells.Secondary {
+				row.SecondarySwells = append(row.SecondarySwells, newSwell(s))
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+func newSwell(s meteo365.Swell) Swell {
+	return Swell{
+		WaveHeightInMeters:           s.WaveHeightInMeters,
+		PeriodInSeconds:              s.PeriodInSeconds,
+		DirectionToInDegrees:         s.DirectionToInDegrees,
+		DirectionFromInCompassPoints: s.DirectionFromInCompassPoints,
+	}
+}
+
+// WriteNDJSON writes the hourly forecasts of a forecast issue to the given writer as
+// newline delimited JSON, one Row per line.
+func WriteNDJSON(w io.Writer, iss *meteo365.ForecastIssue) error {
+	enc := json.NewEncoder(w)
+	for _, row := range Rows(iss) {
+		if err := enc.Encode(row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteCSV writes the hourly forecasts of a forecast issue to the given writer as CSV
+// with a header line. Since hours can have a different number of secondary swells,
+// there are as many secondary swell columns as the most swells an hour has, and the
+// missing ones are left blank.
+func WriteCSV(w io.Writer, iss *meteo365.ForecastIssue) error {
+	rows := Rows(iss)
+
+	var secondarySwells int
+	for _, row := range rows {
+		secondarySwells = max(secondarySwells, len(row.SecondarySwells))
+	}
+
+	header := []string{"timestamp", "rating"}
+	header = append(header, swellColumns("primary_swell")...)
+	for i := range secondarySwells {
+		header = append(header, swellColumns("secondary_swell_"+strconv.Itoa(i+1))...)
+	}
+	header = append(
+		header,
+		"wave_energy_kj",
+		"wind_speed_kmh",
+		"wind_direction_to_deg",
+		"wind_direction_from",
+		"wind_state",
+	)
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.Timestamp.Format(time.RFC3339),
+			strconv.Itoa(row.Rating),
+		}
+		record = append(record, swellValues(row.PrimarySwell)...)
+		for i := range secondarySwells {
+			if i < len(row.SecondarySwells) {
+				record = append(record, swellValues(row.SecondarySwells[i])...)
+			} else {
+				record = append(record, make([]string, len(swellValues(Swell{})))...)
+			}
+		}
+		record = append(
+			record,
+			formatFloat(row.WaveEnergyInKiloJoules),
+			formatFloat(row.Wind.SpeedInKilometersPerHour),
+			formatFloat(row.Wind.DirectionToInDegrees),
+			row.Wind.DirectionFromInCompassPoints,
+			row.Wind.State,
+		)
+
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func swellColumns(prefix string) []string {
+	return []string{
+		prefix + "_wave_height_m",
+		prefix + "_period_s",
+		prefix + "_direction_to_deg",
+		prefix + "_direction_from",
+	}
+}
+
+func swellValues(s Swell) []string {
+	return []string{
+		formatFloat(s.WaveHeightInMeters),
+		formatFloat(s.PeriodInSeconds),
+		formatFloat(s.DirectionToInDegrees),
+		s.DirectionFromInCompassPoints,
+	}
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,115 @@
+package feed
+
+import (
+	"encoding/xml"
+	"io"
+	"time"
+)
+
+// Feed holds an Atom feed as described in RFC 4287.
+type Feed struct {
+	ID       string
+	Title    string
+	Subtitle string
+
+	// SelfURL holds the URL of the feed itself.
+	SelfURL string
+
+	// AlternateURL holds the URL of the page the feed describes.
+	AlternateURL string
+
+	Author  string
+	Updated time.Time
+	Entries []Entry
+}
+
+// Entry holds an entry of an Atom feed.
+type Entry struct {
+	ID      string
+	Title   string
+	URL     string
+	Updated time.Time
+
+	// HTML holds the content of the entry as HTML.
+	HTML string
+}
+
+type atomFeed struct {
+	XMLName  xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID       string      `xml:"id"`
+	Title    string      `xml:"title"`
+	Subtitle string      `xml:"subtitle,omitempty"`
+	Links    []atomLink  `xml:"link"`
+	Author   atomAuthor  `xml:"author"`
+	Updated  string      `xml:"updated"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+	Href string `xml:"href,attr"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomEntry struct {
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// EncodeAtom writes the feed to the given writer as an Atom document.
+func EncodeAtom(w io.Writer, f Feed) error {
+	af := atomFeed{
+		ID:       f.ID,
+		Title:    f.Title,
+		Subtitle: f.Subtitle,
+		Links: []atomLink{
+			{Rel: "self", Type: "application/atom+xml", Href: f.SelfURL},
+			{Rel: "alternate", Type: "text/html", Href: f.AlternateURL},
+		},
+		Author:  atomAuthor{Name: f.Author},
+		Updated: formatTimestamp(f.Updated),
+	}
+
+	for _, e := range f.Entries {
+		af.Entries = append(af.Entries, atomEntry{
+			ID:    e.ID,
+			Title: e.Title,
+			Links: []atomLink{
+				{Rel: "alternate", Type: "text/html", Href: e.URL},
+			},
+			Updated: formatTimestamp(e.Updated),
+			Content: atomContent{
+				Type: "html",
+				Body: e.HTML,
+			},
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(af); err != nil {
+		return err
+	}
+
+	return enc.Close()
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,31 @@
+package geo
+
+import (
+	"math"
+)
+
+// earthRadiusInKilometers is the mean radius of the Earth.
+const earthRadiusInKilometers = 6371.0088
+
+// Point holds geographic coordinates of a location.
+type Point struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// DistanceInKilometers returns the great-circle distance between two points using the
+// haversine formula.
+func DistanceInKilometers(a, b Point) float64 {
+	lat1 := radians(a.Latitude)
+	lat2 := radians(b.Latitude)
+	dLat := lat2 - lat1
+	dLon := radians(b.Longitude - a.Longitude)
+
+	h := math.Pow(math.Sin(dLat/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin(dLon/2), 2)
+
+	return 2 * earthRadiusInKilometers * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+func radians(degrees float64) float64 {
+	return degrees * math.Pi / 180
+}
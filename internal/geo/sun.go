@@ -0,0 +1,50 @@
+package geo
+
+import (
+	"math"
+	"time"
+)
+
+// sunriseElevationInDegrees is the solar elevation at which the sun is considered to rise
+// or set, which accounts for atmospheric refraction and the size of the sun's disk.
+const sunriseElevationInDegrees = -0.833
+
+// IsDaylight checks if the sun is above the horizon at the given point and time.
+func IsDaylight(p Point, t time.Time) bool {
+	return SolarElevationInDegrees(p, t) > sunriseElevationInDegrees
+}
+
+// SolarElevationInDegrees returns the angle between the horizon and the center of the
+// sun at the given point and time using the NOAA general solar position equations,
+// which are accurate to within a few minutes of sunrise and sunset.
+func SolarElevationInDegrees(p Point, t time.Time) float64 {
+	t = t.UTC()
+
+	hour := float64(t.Hour()) + float64(t.Minute())/60 + float64(t.Second())/3600
+
+	// Fractional year in radians.
+	gamma := 2 * math.Pi / 365 * (float64(t.YearDay()) - 1 + (hour-12)/24)
+
+	equationOfTimeInMinutes := 229.18 * (0.000075 +
+		0.001868*math.Cos(gamma) -
+		0.032077*math.Sin(gamma) -
+		0.014615*math.Cos(2*gamma) -
+		0.040849*math.Sin(2*gamma))
+
+	declination := 0.006918 -
+		0.399912*math.Cos(gamma) +
+		0.070257*math.Sin(gamma) -
+		0.006758*math.Cos(2*gamma) +
+		0.000907*math.Sin(2*gamma) -
+		0.002697*math.Cos(3*gamma) +
+		0.00148*math.Sin(3*gamma)
+
+	trueSolarTimeInMinutes := hour*60 + equationOfTimeInMinutes + 4*p.Longitude
+	hourAngle := radians(trueSolarTimeInMinutes/4 - 180)
+
+	lat := radians(p.Latitude)
+	cosZenith := math.Sin(lat)*math.Sin(declination) + math.Cos(lat)*math.Cos(declination)*math.Cos(hourAngle)
+	cosZenith = math.Max(-1, math.Min(1, cosZenith))
+
+	return 90 - math.Acos(cosZenith)*180/math.Pi
+}
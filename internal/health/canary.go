@@ -0,0 +1,118 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// staleProbes is the number of probe intervals after which a successful probe is no
+// longer trusted.
+const staleProbes = 3
+
+// Canary periodically scrapes the forecast of a reference surf break to detect when
+// www.surf-forecast.com is unreachable or its markup changes. It reports the result
+// of the last probe as a Check.
+type Canary struct {
+	scraper  *meteo365.Scraper
+	breakID  int
+	interval time.Duration
+
+	mu          sync.Mutex
+	checkedAt   time.Time
+	lastSuccess time.Time
+	lastErr     error
+}
+
+// NewCanary initializes a new Canary that probes the given surf break at the given
+// interval. The scraper is used directly, so that cached data cannot hide failures.
+func NewCanary(scraper *meteo365.Scraper, breakID int, interval time.Duration) *Canary {
+	return &Canary{
+		scraper:  scraper,
+		breakID:  breakID,
+		interval: interval,
+	}
+}
+
+// Run probes right away and then at the configured interval until the given context
+// is canceled.
+func (c *Canary) Run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		c.Probe()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Probe scrapes the reference surf break and its forecast once and records the result.
+func (c *Canary) Probe() {
+	err := c.probe()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.checkedAt = time.Now()
+	c.lastErr = err
+	if err == nil {
+		c.lastSuccess = c.checkedAt
+	}
+}
+
+func (c *Canary) probe() error {
+	brk, err := c.scraper.Break(c.breakID)
+	if err != nil {
+		return fmt.Errorf("could not scrape surf break %d: %w", c.breakID, err)
+	}
+
+	iss, err := c.scraper.LatestForecastIssue(brk.Slug)
+	if err != nil {
+		return fmt.Errorf("could not scrape forecast of surf break %d: %w", c.breakID, err)
+	}
+	if len(iss.Daily) == 0 {
+		return fmt.Errorf("forecast of surf break %d has no days", c.breakID)
+	}
+
+	return nil
+}
+
+// Check implements Check. It fails if the last probe failed, or if no probe has
+// succeeded for a few intervals.
+func (c *Canary) Check(ctx context.Context) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.checkedAt.IsZero() {
+		return Result{Status: StatusFail, Error: "upstream has not been probed yet"}
+	}
+
+	checkedAt, lastSuccess := c.checkedAt, c.lastSuccess
+
+	res := Result{
+		Status:    StatusOK,
+		CheckedAt: &checkedAt,
+	}
+	if !lastSuccess.IsZero() {
+		res.LastSuccessAt = &lastSuccess
+	}
+
+	switch {
+	case c.lastErr != nil:
+		res.Status = StatusFail
+		res.Error = c.lastErr.Error()
+	case time.Since(c.lastSuccess) > staleProbes*c.interval:
+		res.Status = StatusFail
+		res.Error = "upstream has not been probed recently"
+	}
+
+	return res
+}
@@ -0,0 +1,144 @@
+package health
+
+import (
+	"context"
+	"os"
+	"sync"
+	"time"
+)
+
+// Status is a status of a check.
+type Status string
+
+// Statuses of checks.
+const (
+	StatusOK      Status = "ok"
+	StatusFail    Status = "fail"
+	StatusSkipped Status = "skipped"
+)
+
+// Result holds a result of a check.
+type Result struct {
+	Status Status `json:"status"`
+	Error  string `json:"error,omitempty"`
+
+	// Detail holds a human-readable explanation of the result, i.e. why the check
+	// was skipped.
+	Detail string `json:"detail,omitempty"`
+
+	// CheckedAt holds when the check was last performed if it is performed in the
+	// background rather than on demand.
+	CheckedAt *time.Time `json:"checked_at,omitempty"`
+
+	// LastSuccessAt holds when the check last succeeded if it is performed in the
+	// background rather than on demand.
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+}
+
+// Check checks a dependency of the application.
+type Check interface {
+	Check(ctx context.Context) Result
+}
+
+// CheckFunc is an adapter that allows using an ordinary function as Check. The check
+// fails if the function returns an error.
+type CheckFunc func(ctx context.Context) error
+
+// Check implements Check.
+func (fn CheckFunc) Check(ctx context.Context) Result {
+	if err := fn(ctx); err != nil {
+		return Result{Status: StatusFail, Error: err.Error()}
+	}
+	return Result{Status: StatusOK}
+}
+
+// Skipped returns a check that is always skipped for the given reason.
+func Skipped(reason string) Check {
+	return skipped(reason)
+}
+
+type skipped string
+
+func (s skipped) Check(ctx context.Context) Result {
+	return Result{Status: StatusSkipped, Detail: string(s)}
+}
+
+// Checker runs a set of named checks. It is safe for concurrent use once all checks
+// are added.
+type Checker struct {
+	names  []string
+	checks map[string]Check
+}
+
+// NewChecker initializes a new Checker without checks.
+func NewChecker() *Checker {
+	return &Checker{checks: make(map[string]Check)}
+}
+
+// Add adds a named check.
+func (c *Checker) Add(name string, check Check) {
+	if _, ok := c.checks[name]; !ok {
+		c.names = append(c.names, name)
+	}
+	c.checks[name] = check
+}
+
+// Report holds results of all checks. Status is StatusOK only if none of the checks
+// failed.
+type Report struct {
+	Status Status            `json:"status"`
+	Checks map[string]Result `json:"checks,omitempty"`
+}
+
+// Report runs all checks concurrently and reports their results.
+func (c *Checker) Report(ctx context.Context) Report {
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+
+		report = Report{
+			Status: StatusOK,
+			Checks: make(map[string]Result, len(c.names)),
+		}
+	)
+	for _, name := range c.names {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			res := c.checks[name].Check(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Checks[name] = res
+			if res.Status == StatusFail {
+				report.Status = StatusFail
+			}
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// DirWritable returns a check that verifies that files can be created in a directory.
+func DirWritable(dir string) CheckFunc {
+	return func(ctx context.Context) error {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+
+		f, err := os.CreateTemp(dir, ".healthcheck-*")
+		if err != nil {
+			return err
+		}
+
+		name := f.Name()
+		if err := f.Close(); err != nil {
+			os.Remove(name)
+			return err
+		}
+		return os.Remove(name)
+	}
+}
@@ -0,0 +1,243 @@
+package ical
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Calendar holds an iCalendar object as described in RFC 5545.
+type Calendar struct {
+	ProductID string
+	Name      string
+
+	// TimeZone holds an IANA name of the time zone of the events, whose rules are
+	// taken from Location. The events' times are written in the local time of the
+	// time zone along with a VTIMEZONE component that describes it. They are written
+	// in UTC if either of them is missing.
+	TimeZone string
+	Location *time.Location
+
+	Events []Event
+}
+
+// Event holds a VEVENT component.
+type Event struct {
+	// UID identifies the event across updates of the calendar, so it must stay the
+	// same for calendar clients to update the event rather than duplicate it.
+	UID string
+
+	// Sequence holds a revision number of the event, which must increase whenever
+	// the event changes.
+	Sequence int
+
+	Stamp       time.Time
+	Start       time.Time
+	End         time.Time
+	Summary     string
+	Description string
+	URL         string
+}
+
+// maxLineLength is the maximum length of a content line in octets excluding the line break.
+const maxLineLength = 75
+
+const (
+	// timestampLayout is the layout of UTC DATE-TIME values.
+	timestampLayout = "20060102T150405Z"
+
+	// localTimestampLayout is the layout of local DATE-TIME values.
+	localTimestampLayout = "20060102T150405"
+)
+
+// Encode writes the calendar to the given writer.
+func Encode(w io.Writer, c Calendar) error {
+	bw := bufio.NewWriter(w)
+
+	writeLine(bw, "BEGIN:VCALENDAR")
+	writeLine(bw, "VERSION:2.0")
+	writeLine(bw, "PRODID:"+escapeText(c.ProductID))
+	writeLine(bw, "CALSCALE:GREGORIAN")
+	writeLine(bw, "METHOD:PUBLISH")
+	if c.Name != "" {
+		writeLine(bw, "X-WR-CALNAME:"+escapeText(c.Name))
+	}
+
+	// Times are written in UTC unless the time zone can be described.
+	formatTime := func(name string, t time.Time) string {
+		return name + ":" + formatTimestamp(t)
+	}
+	if c.TimeZone != "" && c.Location != nil {
+		writeLine(bw, "X-WR-TIMEZONE:"+escapeText(c.TimeZone))
+
+		if len(c.Events) > 0 {
+			writeTimeZone(bw, c.TimeZone, c.Location, c.Events)
+		}
+
+		tzid := quoteParam(c.TimeZone)
+		formatTime = func(name string, t time.Time) string {
+			return name + ";TZID=" + tzid + ":" + t.In(c.Location).Format(localTimestampLayout)
+		}
+	}
+
+	for _, e := range c.Events {
+		writeLine(bw, "BEGIN:VEVENT")
+		writeLine(bw, "UID:"+escapeText(e.UID))
+		writeLine(bw, "SEQUENCE:"+strconv.Itoa(e.Sequence))
+		writeLine(bw, "DTSTAMP:"+formatTimestamp(e.Stamp))
+		writeLine(bw, formatTime("DTSTART", e.Start))
+		writeLine(bw, formatTime("DTEND", e.End))
+		writeLine(bw, "SUMMARY:"+escapeText(e.Summary))
+		if e.Description != "" {
+			writeLine(bw, "DESCRIPTION:"+escapeText(e.Description))
+		}
+		if e.URL != "" {
+			writeLine(bw, "URL:"+e.URL)
+		}
+		writeLine(bw, "TRANSP:TRANSPARENT")
+		writeLine(bw, "END:VEVENT")
+	}
+
+	writeLine(bw, "END:VCALENDAR")
+
+	return bw.Flush()
+}
+
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampLayout)
+}
+
+// writeTimeZone writes a VTIMEZONE component that describes the offsets of the given
+// location over the time span of the given events. Each offset the location switches
+// to within the span is written as an observance of its own.
+func writeTimeZone(w *bufio.Writer, tzid string, loc *time.Location, events []Event) {
+	from, to := events[0].Start, events[0].End
+	for _, e := range events {
+		if e.Start.Before(from) {
+			from = e.Start
+		}
+		if e.End.After(to) {
+			to = e.End
+		}
+	}
+
+	writeLine(w, "BEGIN:VTIMEZONE")
+	writeLine(w, "TZID:"+escapeText(tzid))
+
+	// The first observance starts along with the earliest event.
+	from = from.In(loc).Truncate(time.Second)
+	_, offset := from.Zone()
+	writeObservance(w, from, offset)
+
+	for t := from; t.Before(to); {
+		next := t.Add(time.Hour)
+		if sameZone(t, next) {
+			t = next
+			continue
+		}
+
+		// The transition happens within the hour, so it is narrowed down to a second.
+		lo, hi := t, next
+		for hi.Sub(lo) > time.Second {
+			mid := lo.Add(hi.Sub(lo) / 2).Truncate(time.Second)
+			if sameZone(lo, mid) {
+				lo = mid
+			} else {
+				hi = mid
+			}
+		}
+
+		_, offset := lo.Zone()
+		writeObservance(w, hi, offset)
+		t = hi
+	}
+
+	writeLine(w, "END:VTIMEZONE")
+}
+
+// writeObservance writes a STANDARD or DAYLIGHT component of the offset that starts at
+// the given time, which switches from the given offset.
+func writeObservance(w *bufio.Writer, onset time.Time, offsetFrom int) {
+	name, offsetTo := onset.Zone()
+
+	component := "STANDARD"
+	if onset.IsDST() {
+		component = "DAYLIGHT"
+	}
+
+	writeLine(w, "BEGIN:"+component)
+	// The onset is given in the local time that is in effect before it.
+	writeLine(w, "DTSTART:"+onset.UTC().Add(time.Duration(offsetFrom)*time.Second).Format(localTimestampLayout))
+	writeLine(w, "TZOFFSETFROM:"+formatOffset(offsetFrom))
+	writeLine(w, "TZOFFSETTO:"+formatOffset(offsetTo))
+	if name != "" {
+		writeLine(w, "TZNAME:"+escapeText(name))
+	}
+	writeLine(w, "END:"+component)
+}
+
+func sameZone(a, b time.Time) bool {
+	aName, aOffset := a.Zone()
+	bName, bOffset := b.Zone()
+	return aName == bName && aOffset == bOffset && a.IsDST() == b.IsDST()
+}
+
+// formatOffset formats a UTC offset in seconds as a UTC-OFFSET value (i.e. "+0100").
+func formatOffset(offset int) string {
+	sign := "+"
+	if offset < 0 {
+		sign = "-"
+		offset = -offset
+	}
+
+	s := fmt.Sprintf("%s%02d%02d", sign, offset/3600, offset%3600/60)
+	if seconds := offset % 60; seconds != 0 {
+		s += fmt.Sprintf("%02d", seconds)
+	}
+	return s
+}
+
+// quoteParam quotes a parameter value if it contains characters that are not allowed
+// in unquoted values.
+func quoteParam(s string) string {
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+// escapeText escapes a TEXT value.
+func escapeText(s string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(s)
+}
+
+// writeLine writes a content line folding it into multiple lines if it is too long.
+// Lines are never split in the middle of a multi-byte character.
+func writeLine(w *bufio.Writer, line string) {
+	limit := maxLineLength
+	for len(line) > limit {
+		i := limit
+		for i > 0 && !utf8.RuneStart(line[i]) {
+			i--
+		}
+
+		w.WriteString(line[:i])
+		w.WriteString("\r\n ")
+		line = line[i:]
+
+		// Continuation lines start with a space which counts towards the limit.
+		limit = maxLineLength - 1
+	}
+	w.WriteString(line)
+	w.WriteString("\r\n")
+}
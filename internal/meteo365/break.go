@@ -11,6 +11,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/ztimes2/glassy/internal/geo"
 	"github.com/ztimes2/glassy/internal/htmlutil"
 	"golang.org/x/net/html"
 )
@@ -20,11 +21,11 @@ var (
 	ErrBreakNotFound = errors.New("surf break not found")
 )
 
-// SearchBreaks searches for surf breaks using a text query.
-func (s *Scraper) SearchBreaks(query string) ([]BreakSearchResult, error) {
+// Search searches for surf breaks, regions and countries using a text query.
+func (s *Scraper) Search(query string) (SearchResults, error) {
 	u, err := url.Parse(s.baseURL + "/breaks/ac_location_name")
 	if err != nil {
-		return nil, fmt.Errorf("could not prepare request url: %w", err)
+		return SearchResults{}, fmt.Errorf("could not prepare request url: %w", err)
 	}
 
 	u.RawQuery = url.Values{
@@ -33,24 +34,35 @@ func (s *Scraper) SearchBreaks(query string) ([]BreakSearchResult, error) {
 
 	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
 	if err != nil {
-		return nil, fmt.Errorf("could not prepare request: %w", err)
+		return SearchResults{}, fmt.Errorf("could not prepare request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("could not send request: %w", err)
-	}
+	var results SearchResults
+	err = s.fetch("Search", req, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			return &StatusError{StatusCode: resp.StatusCode}
+		}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("received response with %d status code", resp.StatusCode)
-	}
+		body, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("could not read response body: %w", err)
+		}
 
-	defer resp.Body.Close()
-	body, err := io.ReadAll(resp.Body)
+		results, err = parseSearchResults(body)
+		if err != nil {
+			return &ParseError{Err: err}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not read response body: %w", err)
+		return SearchResults{}, err
 	}
 
+	return results, nil
+}
+
+// parseSearchResults parses the payload of a search response.
+func parseSearchResults(body []byte) (SearchResults, error) {
 	// The search response's payload contains a 2D JSON-alike array of strings
 	// that uses single quotes to represent a string.
 	//
@@ -60,15 +72,15 @@ func (s *Scraper) SearchBreaks(query string) ([]BreakSearchResult, error) {
 	// order to make JSON unmarshaling work properly.
 	body = bytes.ReplaceAll(body, []byte(`'`), []byte(`"`))
 
-	var results [][]string
-	if err := json.Unmarshal(body, &results); err != nil {
-		return nil, fmt.Errorf("could not unmarshal response body: %w", err)
+	var payload [][]string
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return SearchResults{}, fmt.Errorf("could not unmarshal response body: %w", err)
 	}
 
-	var breaks []BreakSearchResult
-	for _, result := range results {
+	var results SearchResults
+	for _, result := range payload {
 		if len(result) != 3 {
-			return nil, fmt.Errorf("unexpected search result: %q", result)
+			return SearchResults{}, fmt.Errorf("unexpected search result: %q", result)
 		}
 
 		// Each search result can represent either a surf break, a region, a country, or
@@ -79,30 +91,105 @@ func (s *Scraper) SearchBreaks(query string) ([]BreakSearchResult, error) {
 		// contain special prefixes like "re" for regions (i.e. "re123", "re456", etc.),
 		// "co" for countries (i.e. "co123", "co456", etc.), and so on.
 		//
-		// Therefore, let's ignore results that have non-numerical IDs since we are only
-		// interested in returning surf breaks.
-		id, err := strconv.Atoi(result[0])
-		if err != nil {
+		// Results of any other type are ignored since there is no way to present them yet.
+		switch kind, id, ok := parseLocationID(result[0]); {
+		case !ok:
 			continue
+		case kind == locationKindBreak:
+			results.Breaks = append(results.Breaks, BreakSearchResult{
+				ID:          id,
+				Name:        result[1],
+				CountryName: result[2],
+			})
+		case kind == locationKindRegion:
+			results.Regions = append(results.Regions, RegionSearchResult{
+				ID:          id,
+				Name:        result[1],
+				CountryName: result[2],
+			})
+		case kind == locationKindCountry:
+			results.Countries = append(results.Countries, CountrySearchResult{
+				ID:   id,
+				Name: result[1],
+			})
 		}
-
-		breaks = append(breaks, BreakSearchResult{
-			ID:          id,
-			Name:        result[1],
-			CountryName: result[2],
-		})
 	}
 
-	return breaks, nil
+	return results, nil
 }
 
-// BreakSearchResult holds information about a result of searching for surf breaks.
+// SearchResults holds results of searching for surf breaks, regions and countries.
+type SearchResults struct {
+	Breaks    []BreakSearchResult
+	Regions   []RegionSearchResult
+	Countries []CountrySearchResult
+}
+
+// Empty checks if nothing was found.
+func (r SearchResults) Empty() bool {
+	return len(r.Breaks) == 0 && len(r.Regions) == 0 && len(r.Countries) == 0
+}
+
+// BreakSearchResult holds information about a surf break found by searching.
 type BreakSearchResult struct {
 	ID          int
 	Name        string
 	CountryName string
 }
 
+// RegionSearchResult holds information about a region found by searching.
+type RegionSearchResult struct {
+	ID          int
+	Name        string
+	CountryName string
+}
+
+// CountrySearchResult holds information about a country found by searching.
+type CountrySearchResult struct {
+	ID   int
+	Name string
+}
+
+type locationKind int
+
+const (
+	locationKindBreak locationKind = iota
+	locationKindRegion
+	locationKindCountry
+)
+
+// locationIDPrefixes maps prefixes of location IDs used by www.surf-forecast.com to
+// the kinds of locations they represent. Surf breaks have no prefix.
+var locationIDPrefixes = map[locationKind]string{
+	locationKindBreak:   "",
+	locationKindRegion:  "re",
+	locationKindCountry: "co",
+}
+
+// parseLocationID splits a location ID into its kind and numerical part. It returns
+// false for IDs of unsupported kinds.
+func parseLocationID(s string) (locationKind, int, bool) {
+	for kind, prefix := range locationIDPrefixes {
+		rest, ok := strings.CutPrefix(s, prefix)
+		if !ok {
+			continue
+		}
+
+		id, err := strconv.Atoi(rest)
+		if err != nil {
+			continue
+		}
+
+		return kind, id, true
+	}
+	return 0, 0, false
+}
+
+// formatLocationID is the opposite of parseLocationID.
+func formatLocationID(kind locationKind, id int) string {
+	return locationIDPrefixes[kind] + strconv.Itoa(id)
+}
+
 // Break returns a surf break by its ID. It returns ErrBreakNotFound for non-existent surf breaks.
 func (s *Scraper) Break(id int) (Break, error) {
 	slug, err := s.breakSlug(id)
@@ -117,27 +204,28 @@ func (s *Scraper) Break(id int) (Break, error) {
 		return Break{}, fmt.Errorf("could not prepare request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return Break{}, fmt.Errorf("could not send request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusNotFound {
-			return Break{}, ErrBreakNotFound
+	var b Break
+	err = s.fetch("Break", req, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			if resp.StatusCode == http.StatusNotFound {
+				return ErrBreakNotFound
+			}
+			return &StatusError{StatusCode: resp.StatusCode}
 		}
-		return Break{}, fmt.Errorf("received response with %d status code", resp.StatusCode)
-	}
 
-	defer resp.Body.Close()
-	node, err := html.Parse(resp.Body)
-	if err != nil {
-		return Break{}, fmt.Errorf("could not parse response body as html: %w", err)
-	}
+		node, err := html.Parse(resp.Body)
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("could not parse response body as html: %w", err)}
+		}
 
-	b, err := scrapeSurfBreak(node)
+		b, err = scrapeSurfBreak(node)
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("could not scrape surf break: %w", err)}
+		}
+		return nil
+	})
 	if err != nil {
-		return Break{}, fmt.Errorf("could not scrape surf break: %w", err)
+		return Break{}, err
 	}
 
 	b.ID = id
@@ -152,27 +240,24 @@ type Break struct {
 	Slug        string
 	Name        string
 	CountryName string
+
+	// RegionID holds an ID of the region the surf break is located within. It is
+	// zero if the region is unknown.
+	RegionID int
+
+	// Location holds coordinates of the surf break. It is nil if the coordinates
+	// are unknown.
+	Location *geo.Point
 }
 
 // breakSlug returns a surf break's slug by its ID. It returns ErrBreakNotFound for non-existent surf breaks.
 func (s *Scraper) breakSlug(id int) (string, error) {
-	resp, err := s.client.PostForm(s.baseURL+"/breaks/catch", url.Values{
-		"loc_id": []string{strconv.Itoa(id)},
-	})
-	if err != nil {
-		return "", fmt.Errorf("could not send request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusFound {
-		return "", fmt.Errorf("received response with %d status code", resp.StatusCode)
-	}
-
-	redirectURL, err := url.Parse(resp.Header.Get("Location"))
+	path, err := s.catchLocation(formatLocationID(locationKindBreak, id))
 	if err != nil {
-		return "", fmt.Errorf("could not parse redirect url: %w", err)
+		return "", err
 	}
 
-	path, ok := strings.CutPrefix(redirectURL.Path, "/breaks/")
+	path, ok := strings.CutPrefix(path, "/breaks/")
 	if !ok {
 		return "", ErrBreakNotFound
 	}
@@ -185,6 +270,40 @@ func (s *Scraper) breakSlug(id int) (string, error) {
 	return parts[0], nil
 }
 
+// catchLocation resolves a location ID into a path of the page www.surf-forecast.com
+// redirects to when the location is picked from its navigation form.
+func (s *Scraper) catchLocation(locID string) (string, error) {
+	body := url.Values{
+		"loc_id": []string{locID},
+	}.Encode()
+
+	req, err := http.NewRequest(http.MethodPost, s.baseURL+"/breaks/catch", strings.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("could not prepare request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var path string
+	err = s.fetch("catchLocation", req, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusFound {
+			return &StatusError{StatusCode: resp.StatusCode}
+		}
+
+		redirectURL, err := url.Parse(resp.Header.Get("Location"))
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("could not parse redirect url: %w", err)}
+		}
+
+		path = redirectURL.Path
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
 func scrapeSurfBreak(n *html.Node) (Break, error) {
 	navNode, ok := htmlutil.FindOne(n, htmlutil.WithIDEqual("dropformcont-nav"))
 	if !ok {
@@ -224,5 +343,69 @@ func scrapeSurfBreak(n *html.Node) (Break, error) {
 	return Break{
 		Name:        breakNameTextNode.Data,
 		CountryName: countryNameTextNode.Data,
+		RegionID:    scrapeRegionID(navNode),
+		Location:    scrapeLocation(n),
 	}, nil
 }
+
+// scrapeRegionID returns an ID of the region selected in the navigation. It returns
+// zero if the region cannot be found since it is not essential for a surf break.
+func scrapeRegionID(n *html.Node) int {
+	regionNode, ok := htmlutil.FindOne(n, htmlutil.WithIDEqual("region_id"))
+	if !ok {
+		return 0
+	}
+
+	regionOptionNode, ok := htmlutil.FindOne(regionNode, htmlutil.WithAttribute("selected"))
+	if !ok {
+		return 0
+	}
+
+	valueAttr, ok := htmlutil.Attribute(regionOptionNode, "value")
+	if !ok {
+		return 0
+	}
+
+	id, err := strconv.Atoi(valueAttr.Val)
+	if err != nil {
+		return 0
+	}
+
+	return id
+}
+
+// scrapeLocation scrapes coordinates of a surf break from the geo.position meta tag
+// which holds latitude and longitude separated by a semicolon (i.e. "38.7;-9.4").
+// It returns nil if the tag is missing or malformed since the location is not
+// essential for a surf break.
+func scrapeLocation(n *html.Node) *geo.Point {
+	metaNode, ok := htmlutil.FindOne(n, htmlutil.WithAttributeEqual("name", "geo.position"))
+	if !ok {
+		return nil
+	}
+
+	contentAttr, ok := htmlutil.Attribute(metaNode, "content")
+	if !ok {
+		return nil
+	}
+
+	latText, lonText, ok := strings.Cut(contentAttr.Val, ";")
+	if !ok {
+		return nil
+	}
+
+	lat, err := strconv.ParseFloat(strings.TrimSpace(latText), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return nil
+	}
+
+	lon, err := strconv.ParseFloat(strings.TrimSpace(lonText), 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return nil
+	}
+
+	return &geo.Point{
+		Latitude:  lat,
+		Longitude: lon,
+	}
+}
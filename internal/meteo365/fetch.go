@@ -0,0 +1,187 @@
+package meteo365
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/metrics"
+)
+
+// ParseError indicates that a response of www.surf-forecast.com could not be parsed
+// or scraped, which most likely means that its markup has changed.
+type ParseError struct {
+	Err error
+}
+
+// Error implements error.
+func (e *ParseError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// StatusError indicates that www.surf-forecast.com responded with an unexpected
+// status code.
+type StatusError struct {
+	StatusCode int
+}
+
+// Error implements error.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("received response with %d status code", e.StatusCode)
+}
+
+// Outcomes of upstream calls.
+const (
+	outcomeOK          = "ok"
+	outcomeNotFound    = "not_found"
+	outcomeParseFailed = "parse_failed"
+	outcomeCircuitOpen = "circuit_open"
+	outcomeRejected    = "rejected"
+	outcomeFailed      = "failed"
+)
+
+// fetch sends a request to www.surf-forecast.com on behalf of the named scraper method
+// and passes the response to fn for processing. The call is logged along with the
+// outcome of fn once it is processed.
+func (s *Scraper) fetch(name string, req *http.Request, fn func(resp *http.Response) error) error {
+	start := time.Now()
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		s.observeCall(name, req, 0, 0, time.Since(start), err)
+		return &sendError{err: err}
+	}
+	defer resp.Body.Close()
+
+	body := &countingReader{r: resp.Body}
+	resp.Body = body
+
+	err = fn(resp)
+
+	// Drain what is left of the body so that the bytes are counted in full and the
+	// connection can be reused.
+	_, _ = io.Copy(io.Discard, body)
+
+	s.observeCall(name, req, resp.StatusCode, body.n, time.Since(start), err)
+
+	return err
+}
+
+// observeCall logs an upstream call and records its metrics. Successful calls are
+// logged at the debug level so that they do not flood the logs.
+func (s *Scraper) observeCall(name string, req *http.Request, status int, bytes int64, d time.Duration, err error) {
+	outcome := callOutcome(err)
+
+	s.metrics.calls.Inc(name, outcome)
+	s.metrics.callDuration.Observe(d.Seconds(), name)
+	if outcome == outcomeParseFailed {
+		var (
+			row    string
+			rowErr *rowError
+		)
+		if errors.As(err, &rowErr) {
+			row = rowErr.row
+		}
+		s.metrics.parseFailures.Inc(name, row)
+	}
+
+	level := slog.LevelDebug
+	attrs := []slog.Attr{
+		slog.String("call", name),
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Int("status", status),
+		slog.Duration("duration", d),
+		slog.Int64("bytes", bytes),
+		slog.String("outcome", outcome),
+	}
+	if err != nil && outcome != outcomeNotFound {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+
+	s.logger.LogAttrs(req.Context(), level, "upstream call", attrs...)
+}
+
+func callOutcome(err error) string {
+	var parseErr *ParseError
+	switch {
+	case err == nil:
+		return outcomeOK
+	case errors.As(err, &parseErr):
+		return outcomeParseFailed
+	case errors.Is(err, ErrBreakNotFound), errors.Is(err, errListingNotFound):
+		return outcomeNotFound
+	case errors.Is(err, ErrCircuitOpen):
+		return outcomeCircuitOpen
+	case errors.Is(err, ErrThrottled), errors.Is(err, ErrDisallowedByRobots):
+		return outcomeRejected
+	default:
+		return outcomeFailed
+	}
+}
+
+// scraperMetrics holds metrics of upstream calls.
+type scraperMetrics struct {
+	calls         *metrics.Counter
+	callDuration  *metrics.Histogram
+	parseFailures *metrics.Counter
+}
+
+func newScraperMetrics(r *metrics.Registry) scraperMetrics {
+	return scraperMetrics{
+		calls: r.NewCounter(
+			"glassy_upstream_requests_total",
+			"Number of requests sent to www.surf-forecast.com by scraper method and outcome.",
+			"call", "outcome",
+		),
+		callDuration: r.NewHistogram(
+			"glassy_upstream_request_duration_seconds",
+			"Duration of requests sent to www.surf-forecast.com by scraper method.",
+			metrics.DefaultBuckets,
+			"call",
+		),
+		parseFailures: r.NewCounter(
+			"glassy_scraper_parse_failures_total",
+			"Number of responses of www.surf-forecast.com that could not be scraped by scraper method and forecast row. The row is empty for pages other than forecasts.",
+			"call", "row",
+		),
+	}
+}
+
+// sendError indicates that a request could not be sent.
+type sendError struct {
+	err error
+}
+
+func (e *sendError) Error() string {
+	return "could not send request: " + e.err.Error()
+}
+
+func (e *sendError) Unwrap() error {
+	return e.err
+}
+
+// countingReader counts the bytes read from the underlying reader.
+type countingReader struct {
+	r io.ReadCloser
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func (c *countingReader) Close() error {
+	return c.r.Close()
+}
@@ -25,27 +25,28 @@ func (s *Scraper) LatestForecastIssue(slug string) (*ForecastIssue, error) {
 		return nil, fmt.Errorf("could not prepare request: %w", err)
 	}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("could not send request: %w", err)
-	}
-
-	if resp.StatusCode != http.StatusOK {
-		if resp.StatusCode == http.StatusNotFound {
-			return nil, ErrBreakNotFound
+	var forecast *ForecastIssue
+	err = s.fetch("LatestForecastIssue", req, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			if resp.StatusCode == http.StatusNotFound {
+				return ErrBreakNotFound
+			}
+			return &StatusError{StatusCode: resp.StatusCode}
 		}
-		return nil, fmt.Errorf("received response with %d status code", resp.StatusCode)
-	}
 
-	defer resp.Body.Close()
-	node, err := html.Parse(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("could not parse response body as html: %w", err)
-	}
+		node, err := html.Parse(resp.Body)
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("could not parse response body as html: %w", err)}
+		}
 
-	forecast, err := scrapeForecast(node, s.timezone)
+		forecast, err = scrapeForecast(node, s.timezone)
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("could not scrape html: %w", err)}
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape html: %w", err)
+		return nil, err
 	}
 
 	return forecast, nil
@@ -59,6 +60,42 @@ type ForecastIssue struct {
 	Daily    []*DailyForecast
 }
 
+// Current returns the hourly forecast that is in effect at the given time, which is
+// the latest one that starts at or before it. It returns false if the forecast issue
+// does not cover the given time, i.e. if the time is before the first hourly forecast
+// or after the last one has ended. The last one is assumed to last as long as the one
+// before it.
+func (f *ForecastIssue) Current(t time.Time) (HourlyForecast, bool) {
+	var hourly []HourlyForecast
+	for _, df := range f.Daily {
+		hourly = append(hourly, df.Hourly...)
+	}
+
+	last := len(hourly) - 1
+	for i := last; i >= 0; i-- {
+		hf := hourly[i]
+		if hf.Timestamp.After(t) {
+			continue
+		}
+
+		if i == last {
+			if i == 0 {
+				// The duration of a single hourly forecast is unknown.
+				return hf, hf.Timestamp.Equal(t)
+			}
+
+			end := hf.Timestamp.Add(hf.Timestamp.Sub(hourly[i-1].Timestamp))
+			if !t.Before(end) {
+				return HourlyForecast{}, false
+			}
+		}
+
+		return hf, true
+	}
+
+	return HourlyForecast{}, false
+}
+
 // newForecastIssue combines the scraped forecast data into ForecastIssue.
 func newForecastIssue(
 	issuedAt time.Time,
@@ -235,50 +272,50 @@ type Wind struct {
 func scrapeForecast(n *html.Node, tz *timezone.Timezone) (*ForecastIssue, error) {
 	issuedAt, err := scrapeIssueTimestamp(n, tz)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape issue date: %w", err)
+		return nil, &rowError{row: "issue_date", err: fmt.Errorf("could not scrape issue date: %w", err)}
 	}
 
 	tableNode, ok := htmlutil.FindOne(n, htmlutil.WithClassEqual("forecast-table__basic"))
 	if !ok {
-		return nil, errors.New("could not find table node")
+		return nil, &rowError{row: "table", err: errors.New("could not find table node")}
 	}
 
 	days, err := scrapeDays(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape days: %w", err)
+		return nil, &rowError{row: "days", err: fmt.Errorf("could not scrape days: %w", err)}
 	}
 
 	hours, err := scrapeHours(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape hours: %w", err)
+		return nil, &rowError{row: "hours", err: fmt.Errorf("could not scrape hours: %w", err)}
 	}
 
 	ratings, err := scrapeRatings(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape ratings: %w", err)
+		return nil, &rowError{row: "ratings", err: fmt.Errorf("could not scrape ratings: %w", err)}
 	}
 
 	swells, err := scrapeSwells(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape swells: %w", err)
+		return nil, &rowError{row: "swells", err: fmt.Errorf("could not scrape swells: %w", err)}
 	}
 
 	waveEnergies, err := scrapeWaveEnergies(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape wave energies: %w", err)
+		return nil, &rowError{row: "wave_energies", err: fmt.Errorf("could not scrape wave energies: %w", err)}
 	}
 
 	winds, err := scrapeWinds(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape winds: %w", err)
+		return nil, &rowError{row: "winds", err: fmt.Errorf("could not scrape winds: %w", err)}
 	}
 
 	windStates, err := scrapeWindStates(tableNode)
 	if err != nil {
-		return nil, fmt.Errorf("could not scrape wind states: %w", err)
+		return nil, &rowError{row: "wind_states", err: fmt.Errorf("could not scrape wind states: %w", err)}
 	}
 
-	return newForecastIssue(
+	iss, err := newForecastIssue(
 		issuedAt,
 		days,
 		hours,
@@ -288,6 +325,25 @@ func scrapeForecast(n *html.Node, tz *timezone.Timezone) (*ForecastIssue, error)
 		winds,
 		windStates,
 	)
+	if err != nil {
+		return nil, &rowError{row: "table", err: err}
+	}
+
+	return iss, nil
+}
+
+// rowError indicates that a row of the forecast table could not be scraped.
+type rowError struct {
+	row string
+	err error
+}
+
+func (e *rowError) Error() string {
+	return e.err.Error()
+}
+
+func (e *rowError) Unwrap() error {
+	return e.err
 }
 
 func scrapeIssueTimestamp(n *html.Node, tz *timezone.Timezone) (time.Time, error) {
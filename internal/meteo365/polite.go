@@ -0,0 +1,468 @@
+package meteo365
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrThrottled indicates that a request was not sent because too many requests
+	// were already waiting for their turn.
+	ErrThrottled = errors.New("throttled: too many requests to www.surf-forecast.com are queued")
+
+	// ErrDisallowedByRobots indicates that a request was not sent because robots.txt
+	// of www.surf-forecast.com disallows it.
+	ErrDisallowedByRobots = errors.New("disallowed by robots.txt")
+)
+
+const (
+	defaultUserAgent      = "glassy (+https://github.com/ztimes2/glassy)"
+	defaultRateLimit      = 2
+	defaultRateBurst      = 5
+	defaultMaxConcurrency = 4
+	defaultMaxQueue       = 32
+
+	// robotsTTL is how long a fetched robots.txt is trusted.
+	robotsTTL = 24 * time.Hour
+
+	// robotsTimeout is the time limit of fetching robots.txt, which is not tied to
+	// the request that needs it.
+	robotsTimeout = 10 * time.Second
+)
+
+// notSentError indicates that a request was not sent at all, so it tells nothing about
+// the health of www.surf-forecast.com.
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *notSentError) Unwrap() error {
+	return e.err
+}
+
+// politeTransport is an http.RoundTripper that paces requests with a token bucket,
+// caps the number of requests in flight, identifies itself with a User-Agent and
+// optionally obeys robots.txt.
+type politeTransport struct {
+	next      http.RoundTripper
+	userAgent string
+	limiter   *limiter
+
+	// robots is nil if robots.txt is not obeyed.
+	robots *robots
+
+	// onQueued is called when a request has to wait for its turn, and onRejected is
+	// called with a reason when a request is not sent. They can be nil.
+	onQueued   func()
+	onRejected func(reason string)
+}
+
+// RoundTrip implements http.RoundTripper. Requests that are not sent fail with
+// *notSentError.
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("User-Agent", t.userAgent)
+
+	if t.robots != nil {
+		allowed, err := t.robots.allowed(req.Context(), req.URL.Path, t.fetchRobots)
+		if err != nil {
+			t.rejected("robots_unavailable")
+			return nil, &notSentError{err: err}
+		}
+		if !allowed {
+			t.rejected("robots")
+			return nil, &notSentError{err: ErrDisallowedByRobots}
+		}
+	}
+
+	return t.send(req)
+}
+
+// send sends a request once the limiter lets it through.
+func (t *politeTransport) send(req *http.Request) (*http.Response, error) {
+	release, queued, err := t.limiter.acquire(req.Context())
+	if queued && t.onQueued != nil {
+		t.onQueued()
+	}
+	if err != nil {
+		if errors.Is(err, ErrThrottled) {
+			t.rejected("queue_full")
+		} else {
+			t.rejected("canceled")
+		}
+		return nil, &notSentError{err: err}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	// The slot is held until the body is closed, since that is when the request
+	// actually finishes.
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+
+	return resp, nil
+}
+
+// fetchRobots fetches robots.txt, which is paced like any other request, and returns
+// its rules along with how long they can be trusted. A missing robots.txt allows
+// everything.
+func (t *politeTransport) fetchRobots(ctx context.Context) ([]robotsRule, time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, t.robots.baseURL+"/robots.txt", nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not prepare request: %w", err)
+	}
+	req.Header.Set("User-Agent", t.userAgent)
+
+	resp, err := t.send(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return parseRobots(io.LimitReader(resp.Body, 512<<10), robotsToken(t.userAgent)), robotsTTL, nil
+	case resp.StatusCode >= 400 && resp.StatusCode < 500:
+		return nil, robotsTTL, nil
+	default:
+		return nil, 0, &StatusError{StatusCode: resp.StatusCode}
+	}
+}
+
+func (t *politeTransport) rejected(reason string) {
+	if t.onRejected != nil {
+		t.onRejected(reason)
+	}
+}
+
+// releasingBody calls release once the body is closed.
+type releasingBody struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
+
+// limiter combines a token bucket, which paces requests, with a cap on the number of
+// requests in flight. Requests wait for their turn unless too many are waiting
+// already.
+type limiter struct {
+	rate     float64
+	burst    float64
+	slots    chan struct{}
+	maxQueue int
+
+	mu      sync.Mutex
+	tokens  float64
+	last    time.Time
+	waiting int
+}
+
+func newLimiter(rate float64, burst, maxConcurrency, maxQueue int) *limiter {
+	return &limiter{
+		rate:     rate,
+		burst:    float64(burst),
+		slots:    make(chan struct{}, maxConcurrency),
+		maxQueue: maxQueue,
+		tokens:   float64(burst),
+		last:     time.Now(),
+	}
+}
+
+// acquire waits until a request may be sent and returns a function that must be
+// called once the request finishes. It also reports whether the request had to wait.
+func (l *limiter) acquire(ctx context.Context) (release func(), queued bool, err error) {
+	l.mu.Lock()
+
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+
+	delay := time.Duration(0)
+	if l.tokens < 1 {
+		delay = time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	}
+
+	slotFree := len(l.slots) < cap(l.slots)
+	queued = delay > 0 || !slotFree
+
+	if queued && l.waiting >= l.maxQueue {
+		l.mu.Unlock()
+		return nil, false, ErrThrottled
+	}
+
+	// The token is taken right away, so that the requests that come next wait for
+	// their own tokens.
+	l.tokens--
+	if queued {
+		l.waiting++
+	}
+	l.mu.Unlock()
+
+	if queued {
+		defer func() {
+			l.mu.Lock()
+			l.waiting--
+			l.mu.Unlock()
+		}()
+	}
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			l.refund()
+			return nil, queued, ctx.Err()
+		case <-timer.C:
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		l.refund()
+		return nil, queued, ctx.Err()
+	case l.slots <- struct{}{}:
+	}
+
+	var once sync.Once
+	return func() { once.Do(func() { <-l.slots }) }, queued, nil
+}
+
+func (l *limiter) refund() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.tokens = min(l.burst, l.tokens+1)
+}
+
+// stats returns the numbers of requests in flight and waiting for their turn.
+func (l *limiter) stats() (inFlight, waiting int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.slots), l.waiting
+}
+
+// robots caches robots.txt of the host requests are sent to.
+type robots struct {
+	baseURL string
+
+	mu        sync.Mutex
+	rules     []robotsRule
+	fetched   bool
+	expiresAt time.Time
+
+	// fetching is the fetch of robots.txt in progress. It is nil if none is.
+	fetching *robotsFetch
+}
+
+// robotsFetch is a fetch of robots.txt that requests needing it wait for.
+type robotsFetch struct {
+	done chan struct{}
+	err  error
+}
+
+// robotsRule is an Allow or a Disallow rule of robots.txt.
+type robotsRule struct {
+	allow   bool
+	pattern string
+	re      *regexp.Regexp
+}
+
+func newRobotsRule(allow bool, pattern string) robotsRule {
+	// A pattern is a path prefix that can contain "*" wildcards and end with "$" to
+	// match the end of the path.
+	anchored := strings.HasSuffix(pattern, "$")
+	expr := strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSuffix(pattern, "$")), `\*`, ".*")
+	if anchored {
+		expr += "$"
+	}
+
+	return robotsRule{
+		allow:   allow,
+		pattern: pattern,
+		re:      regexp.MustCompile("^" + expr),
+	}
+}
+
+func newRobots(baseURL string) *robots {
+	return &robots{baseURL: baseURL}
+}
+
+// allowed checks if robots.txt allows a request to the given path. robots.txt is
+// fetched with the given function when it is missing or outdated. Concurrent requests
+// wait for the same fetch, which goes on even if they give up waiting.
+//
+// If robots.txt cannot be fetched, the previously fetched one is used if any, and it
+// is fetched again for the next request. Otherwise, the error is returned.
+func (r *robots) allowed(
+	ctx context.Context,
+	path string,
+	fetch func(context.Context) ([]robotsRule, time.Duration, error),
+) (bool, error) {
+
+	r.mu.Lock()
+	if r.fetched && time.Now().Before(r.expiresAt) {
+		rules := r.rules
+		r.mu.Unlock()
+		return robotsAllowed(rules, path), nil
+	}
+
+	f := r.fetching
+	if f == nil {
+		f = &robotsFetch{done: make(chan struct{})}
+		r.fetching = f
+		go r.fetch(context.WithoutCancel(ctx), f, fetch)
+	}
+	r.mu.Unlock()
+
+	select {
+	case <-ctx.Done():
+		return false, ctx.Err()
+	case <-f.done:
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if f.err != nil && !r.fetched {
+		return false, fmt.Errorf("could not fetch robots.txt: %w", f.err)
+	}
+	return robotsAllowed(r.rules, path), nil
+}
+
+// fetch fetches robots.txt with the given function and caches it unless it fails.
+func (r *robots) fetch(
+	ctx context.Context,
+	f *robotsFetch,
+	fetch func(context.Context) ([]robotsRule, time.Duration, error),
+) {
+
+	ctx, cancel := context.WithTimeout(ctx, robotsTimeout)
+	defer cancel()
+
+	rules, ttl, err := fetch(ctx)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f.err = err
+	r.fetching = nil
+	close(f.done)
+
+	if err != nil {
+		return
+	}
+
+	r.rules = rules
+	r.fetched = true
+	r.expiresAt = time.Now().Add(ttl)
+}
+
+// robotsToken returns the product token of a User-Agent, i.e. "glassy" for
+// "glassy (+https://github.com/ztimes2/glassy)".
+func robotsToken(userAgent string) string {
+	token, _, _ := strings.Cut(userAgent, " ")
+	token, _, _ = strings.Cut(token, "/")
+	return strings.ToLower(token)
+}
+
+// parseRobots returns the rules of the group that matches the given product token,
+// or the rules of the "*" group if none matches.
+func parseRobots(r io.Reader, token string) []robotsRule {
+	var (
+		specific, wildcard []robotsRule
+		matchesToken       bool
+		matchesWildcard    bool
+		foundToken         bool
+
+		// inAgents is true while consecutive User-agent lines of a group are read.
+		inAgents bool
+	)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if !inAgents {
+				matchesToken, matchesWildcard = false, false
+				inAgents = true
+			}
+			switch agent := strings.ToLower(value); {
+			case agent == "*":
+				matchesWildcard = true
+			case agent == token:
+				matchesToken = true
+				foundToken = true
+			}
+		case "allow", "disallow":
+			inAgents = false
+			if value == "" {
+				// An empty Disallow allows everything.
+				continue
+			}
+			rule := newRobotsRule(key == "allow", value)
+			if matchesToken {
+				specific = append(specific, rule)
+			}
+			if matchesWildcard {
+				wildcard = append(wildcard, rule)
+			}
+		default:
+			inAgents = false
+		}
+	}
+
+	if foundToken {
+		return specific
+	}
+	return wildcard
+}
+
+// robotsAllowed checks if a path is allowed by the rules. The most specific, i.e. the
+// longest, matching rule wins, and Allow wins a tie.
+func robotsAllowed(rules []robotsRule, path string) bool {
+	var (
+		allowed = true
+		longest = -1
+	)
+	for _, rule := range rules {
+		if !rule.re.MatchString(path) {
+			continue
+		}
+		if n := len(rule.pattern); n > longest || n == longest && rule.allow {
+			allowed = rule.allow
+			longest = n
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,401 @@
+package meteo365
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestLimiter_Burst(t *testing.T) {
+	l := newLimiter(10, 2, 10, 10)
+
+	for i := 0; i < 2; i++ {
+		release, queued, err := l.acquire(context.Background())
+		if err != nil || queued {
+			t.Fatalf("request %d: expected to be sent right away, got %t, %v", i, queued, err)
+		}
+		release()
+	}
+
+	start := time.Now()
+	release, queued, err := l.acquire(context.Background())
+	if err != nil || !queued {
+		t.Fatalf("expected to wait for a token, got %t, %v", queued, err)
+	}
+	release()
+
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Errorf("expected to wait for about 100ms, waited %v", waited)
+	}
+}
+
+func TestLimiter_Refill(t *testing.T) {
+	l := newLimiter(100, 1, 10, 10)
+
+	release, _, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	release()
+
+	time.Sleep(50 * time.Millisecond)
+
+	release, queued, err := l.acquire(context.Background())
+	if err != nil || queued {
+		t.Fatalf("expected the token to be refilled, got %t, %v", queued, err)
+	}
+	release()
+}
+
+func TestLimiter_MaxQueue(t *testing.T) {
+	l := newLimiter(0.001, 1, 10, 1)
+
+	release, _, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer release()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	waiting := make(chan error)
+	go func() {
+		_, _, err := l.acquire(ctx)
+		waiting <- err
+	}()
+
+	waitFor(t, func() bool {
+		_, n := l.stats()
+		return n == 1
+	})
+
+	if _, _, err := l.acquire(context.Background()); !errors.Is(err, ErrThrottled) {
+		t.Fatalf("expected throttled, got %v", err)
+	}
+
+	cancel()
+	if err := <-waiting; !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+
+	if _, n := l.stats(); n != 0 {
+		t.Errorf("expected no waiting requests, got %d", n)
+	}
+
+	// The canceled request gives its token back.
+	l.mu.Lock()
+	tokens := l.tokens
+	l.mu.Unlock()
+	if tokens < -0.5 {
+		t.Errorf("expected the token to be refunded, got %v tokens", tokens)
+	}
+}
+
+func TestLimiter_MaxConcurrency(t *testing.T) {
+	l := newLimiter(1000, 10, 1, 10)
+
+	release, _, err := l.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if inFlight, _ := l.stats(); inFlight != 1 {
+		t.Errorf("expected 1 request in flight, got %d", inFlight)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	if _, queued, err := l.acquire(ctx); !queued || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected to wait for a slot until the deadline, got %t, %v", queued, err)
+	}
+
+	release()
+	release()
+
+	release, queued, err := l.acquire(context.Background())
+	if err != nil || queued {
+		t.Fatalf("expected the slot to be free, got %t, %v", queued, err)
+	}
+	release()
+
+	if inFlight, _ := l.stats(); inFlight != 0 {
+		t.Errorf("expected no requests in flight, got %d", inFlight)
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	const robotsTxt = `
+# Comments are ignored.
+User-agent: *
+Disallow: /private
+Disallow: /search?*q=
+Allow: /private/public
+Disallow: /*.json$
+
+User-agent: otherbot
+User-agent: glassy
+Disallow: /breaks/*/forecasts/history
+Allow: /breaks/*/forecasts/history/latest
+Disallow:
+
+User-agent: evilbot
+Disallow: /
+`
+
+	tests := []struct {
+		name  string
+		token string
+		path  string
+		want  bool
+	}{
+		{name: "specific group allows what is not disallowed", token: "glassy", path: "/private", want: true},
+		{name: "specific group disallows with a wildcard", token: "glassy", path: "/breaks/Supertubos/forecasts/history", want: false},
+		{name: "longer allow wins", token: "glassy", path: "/breaks/Supertubos/forecasts/history/latest", want: true},
+		{name: "group with several agents", token: "otherbot", path: "/breaks/Supertubos/forecasts/history", want: false},
+		{name: "other groups are ignored", token: "glassy", path: "/", want: true},
+		{name: "wildcard group disallows by prefix", token: "somebot", path: "/private/stuff", want: false},
+		{name: "wildcard group allows a longer rule", token: "somebot", path: "/private/public/stuff", want: true},
+		{name: "wildcard matches within the path", token: "somebot", path: "/search?page=1&q=waves", want: false},
+		{name: "end anchor matches", token: "somebot", path: "/breaks.json", want: false},
+		{name: "end anchor does not match a prefix", token: "somebot", path: "/breaks.json/more", want: true},
+		{name: "unmatched path is allowed", token: "somebot", path: "/breaks", want: true},
+		{name: "everything is disallowed", token: "evilbot", path: "/breaks", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules := parseRobots(strings.NewReader(robotsTxt), tt.token)
+			if got := robotsAllowed(rules, tt.path); got != tt.want {
+				t.Errorf("expected %t, got %t", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRobotsAllowed_TieGoesToAllow(t *testing.T) {
+	rules := []robotsRule{
+		newRobotsRule(false, "/breaks"),
+		newRobotsRule(true, "/breaks"),
+	}
+	if !robotsAllowed(rules, "/breaks/1") {
+		t.Error("expected allow to win a tie")
+	}
+}
+
+func TestRobotsToken(t *testing.T) {
+	tests := []struct {
+		userAgent string
+		want      string
+	}{
+		{userAgent: "glassy (+https://github.com/ztimes2/glassy)", want: "glassy"},
+		{userAgent: "Glassy/1.2 (+https://github.com/ztimes2/glassy)", want: "glassy"},
+		{userAgent: "glassy", want: "glassy"},
+	}
+
+	for _, tt := range tests {
+		if got := robotsToken(tt.userAgent); got != tt.want {
+			t.Errorf("robotsToken(%q): expected %q, got %q", tt.userAgent, tt.want, got)
+		}
+	}
+}
+
+func TestPoliteTransport_Robots(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		robotsStatus  = http.StatusServiceUnavailable
+		robotsFetches int
+		pages         int
+	)
+
+	pt := &politeTransport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			if got := req.Header.Get("User-Agent"); got != defaultUserAgent {
+				t.Errorf("unexpected user agent: %q", got)
+			}
+
+			if req.URL.Path == "/robots.txt" {
+				robotsFetches++
+				resp := newResponse(robotsStatus, nil)
+				resp.Body = http.NoBody
+				if robotsStatus == http.StatusOK {
+					resp = newResponse(http.StatusOK, nil)
+					resp.Body = readCloser("User-agent: *\nDisallow: /private\n")
+				}
+				return resp, nil
+			}
+
+			pages++
+			return newResponse(http.StatusOK, nil), nil
+		}),
+		userAgent: defaultUserAgent,
+		limiter:   newLimiter(0.001, 3, 10, 10),
+		robots:    newRobots("https://www.surf-forecast.com"),
+	}
+
+	get := func(path string) error {
+		req, _ := http.NewRequest(http.MethodGet, "https://www.surf-forecast.com"+path, nil)
+		resp, err := pt.RoundTrip(req)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	// Requests are not sent until robots.txt is fetched.
+	err := get("/breaks")
+	var notSent *notSentError
+	if !errors.As(err, &notSent) {
+		t.Fatalf("expected the request not to be sent, got %v", err)
+	}
+
+	// A failed fetch is not cached.
+	mu.Lock()
+	robotsStatus = http.StatusOK
+	mu.Unlock()
+
+	if err := get("/breaks"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := get("/private/stuff"); !errors.Is(err, ErrDisallowedByRobots) || !errors.As(err, &notSent) {
+		t.Fatalf("expected disallowed by robots, got %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if robotsFetches != 2 {
+		t.Errorf("expected robots.txt to be fetched twice, got %d", robotsFetches)
+	}
+	if pages != 1 {
+		t.Errorf("expected 1 page to be requested, got %d", pages)
+	}
+
+	// The robots.txt fetches are paced like any other request.
+	if inFlight, _ := pt.limiter.stats(); inFlight != 0 {
+		t.Errorf("expected no requests in flight, got %d", inFlight)
+	}
+	pt.limiter.mu.Lock()
+	tokens := pt.limiter.tokens
+	pt.limiter.mu.Unlock()
+	if tokens > 0.5 {
+		t.Errorf("expected robots.txt fetches to take tokens, got %v tokens left", tokens)
+	}
+}
+
+func TestRobots_FetchOutlivesCanceledRequest(t *testing.T) {
+	r := newRobots("https://www.surf-forecast.com")
+
+	var (
+		unblock = make(chan struct{})
+		fetches int
+		mu      sync.Mutex
+	)
+	fetch := func(ctx context.Context) ([]robotsRule, time.Duration, error) {
+		mu.Lock()
+		fetches++
+		mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, 0, ctx.Err()
+		case <-unblock:
+		}
+		return []robotsRule{newRobotsRule(false, "/private")}, time.Hour, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := r.allowed(ctx, "/breaks", fetch); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+
+	// The fetch goes on without the request that started it, and the lock is not
+	// held meanwhile.
+	waitFor(t, func() bool {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		return r.fetching != nil
+	})
+	close(unblock)
+
+	allowed, err := r.allowed(context.Background(), "/private", fetch)
+	if err != nil || allowed {
+		t.Fatalf("expected to be disallowed, got %t, %v", allowed, err)
+	}
+
+	allowed, err = r.allowed(context.Background(), "/breaks", fetch)
+	if err != nil || !allowed {
+		t.Fatalf("expected to be allowed, got %t, %v", allowed, err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if fetches != 1 {
+		t.Errorf("expected robots.txt to be fetched once, got %d", fetches)
+	}
+}
+
+func TestRobots_StaleRulesOnError(t *testing.T) {
+	r := newRobots("https://www.surf-forecast.com")
+
+	fetch := func(context.Context) ([]robotsRule, time.Duration, error) {
+		return []robotsRule{newRobotsRule(false, "/private")}, time.Nanosecond, nil
+	}
+	if _, err := r.allowed(context.Background(), "/breaks", fetch); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	time.Sleep(time.Millisecond)
+
+	var fetches int
+	failing := func(context.Context) ([]robotsRule, time.Duration, error) {
+		fetches++
+		return nil, 0, errors.New("connection reset")
+	}
+
+	for i := 0; i < 2; i++ {
+		allowed, err := r.allowed(context.Background(), "/private", failing)
+		if err != nil || allowed {
+			t.Fatalf("expected the previous rules to be used, got %t, %v", allowed, err)
+		}
+	}
+
+	if fetches != 2 {
+		t.Errorf("expected the failed fetch not to be cached, got %d fetches", fetches)
+	}
+}
+
+func readCloser(s string) *stringReadCloser {
+	return &stringReadCloser{Reader: strings.NewReader(s)}
+}
+
+type stringReadCloser struct {
+	*strings.Reader
+}
+
+func (*stringReadCloser) Close() error {
+	return nil
+}
+
+// waitFor waits until the given condition holds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,269 @@
+package meteo365
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/ztimes2/glassy/internal/htmlutil"
+	"golang.org/x/net/html"
+)
+
+var (
+	// ErrRegionNotFound indicates that a region could not be found.
+	ErrRegionNotFound = errors.New("region not found")
+
+	// ErrCountryNotFound indicates that a country could not be found.
+	ErrCountryNotFound = errors.New("country not found")
+)
+
+// Region returns a region by its ID along with the surf breaks located within it.
+// It returns ErrRegionNotFound for non-existent regions.
+func (s *Scraper) Region(id int) (Region, error) {
+	slug, err := s.locationSlug(formatLocationID(locationKindRegion, id), "/regions/")
+	if err != nil {
+		if errors.Is(err, errUnexpectedLocation) {
+			return Region{}, ErrRegionNotFound
+		}
+		return Region{}, fmt.Errorf("could not fetch slug of region: %w", err)
+	}
+
+	var r Region
+	err = s.breakListing("Region", "/regions/"+slug+"/breaks", func(node *html.Node) error {
+		var err error
+		r, err = scrapeRegion(node)
+		if err != nil {
+			return fmt.Errorf("could not scrape region: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errListingNotFound) {
+			return Region{}, ErrRegionNotFound
+		}
+		return Region{}, err
+	}
+
+	r.ID = id
+	r.Slug = slug
+
+	return r, nil
+}
+
+// Region holds information about a region and the surf breaks located within it.
+type Region struct {
+	ID          int
+	Slug        string
+	Name        string
+	CountryName string
+	Breaks      []BreakSummary
+}
+
+// Country returns a country by its ID along with the surf breaks located within it.
+// It returns ErrCountryNotFound for non-existent countries.
+func (s *Scraper) Country(id int) (Country, error) {
+	slug, err := s.locationSlug(formatLocationID(locationKindCountry, id), "/countries/")
+	if err != nil {
+		if errors.Is(err, errUnexpectedLocation) {
+			return Country{}, ErrCountryNotFound
+		}
+		return Country{}, fmt.Errorf("could not fetch slug of country: %w", err)
+	}
+
+	var c Country
+	err = s.breakListing("Country", "/countries/"+slug+"/breaks", func(node *html.Node) error {
+		var err error
+		c, err = scrapeCountry(node)
+		if err != nil {
+			return fmt.Errorf("could not scrape country: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, errListingNotFound) {
+			return Country{}, ErrCountryNotFound
+		}
+		return Country{}, err
+	}
+
+	c.ID = id
+	c.Slug = slug
+
+	return c, nil
+}
+
+// Country holds information about a country and the surf breaks located within it.
+type Country struct {
+	ID     int
+	Slug   string
+	Name   string
+	Breaks []BreakSummary
+}
+
+// BreakSummary holds brief information about a surf break that is listed within
+// a region or a country.
+type BreakSummary struct {
+	ID   int
+	Name string
+}
+
+var (
+	errUnexpectedLocation = errors.New("unexpected location")
+	errListingNotFound    = errors.New("listing not found")
+)
+
+// locationSlug returns a slug of a region or a country by its location ID. It returns
+// errUnexpectedLocation if www.surf-forecast.com redirects to a path that does not
+// start with the given prefix.
+func (s *Scraper) locationSlug(locID, prefix string) (string, error) {
+	path, err := s.catchLocation(locID)
+	if err != nil {
+		return "", err
+	}
+
+	path, ok := strings.CutPrefix(path, prefix)
+	if !ok {
+		return "", errUnexpectedLocation
+	}
+
+	slug, _, _ := strings.Cut(path, "/")
+	if slug == "" {
+		return "", errors.New("unexpected redirect url format")
+	}
+
+	return slug, nil
+}
+
+// breakListing fetches and parses a page that lists surf breaks on behalf of the
+// named scraper method, and passes it to scrape. It returns errListingNotFound for
+// non-existent pages.
+func (s *Scraper) breakListing(name, path string, scrape func(*html.Node) error) error {
+	req, err := http.NewRequest(http.MethodGet, s.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("could not prepare request: %w", err)
+	}
+
+	return s.fetch(name, req, func(resp *http.Response) error {
+		if resp.StatusCode != http.StatusOK {
+			if resp.StatusCode == http.StatusNotFound {
+				return errListingNotFound
+			}
+			return &StatusError{StatusCode: resp.StatusCode}
+		}
+
+		node, err := html.Parse(resp.Body)
+		if err != nil {
+			return &ParseError{Err: fmt.Errorf("could not parse response body as html: %w", err)}
+		}
+
+		if err := scrape(node); err != nil {
+			return &ParseError{Err: err}
+		}
+		return nil
+	})
+}
+
+func scrapeRegion(n *html.Node) (Region, error) {
+	navNode, ok := htmlutil.FindOne(n, htmlutil.WithIDEqual("dropformcont-nav"))
+	if !ok {
+		return Region{}, errors.New("could not find navigation node")
+	}
+
+	countryName, err := scrapeSelectedOption(navNode, "country_id")
+	if err != nil {
+		return Region{}, fmt.Errorf("could not scrape country name: %w", err)
+	}
+
+	regionName, err := scrapeSelectedOption(navNode, "region_id")
+	if err != nil {
+		return Region{}, fmt.Errorf("could not scrape region name: %w", err)
+	}
+
+	breaks, err := scrapeBreakSummaries(navNode)
+	if err != nil {
+		return Region{}, fmt.Errorf("could not scrape surf breaks: %w", err)
+	}
+
+	return Region{
+		Name:        regionName,
+		CountryName: countryName,
+		Breaks:      breaks,
+	}, nil
+}
+
+func scrapeCountry(n *html.Node) (Country, error) {
+	navNode, ok := htmlutil.FindOne(n, htmlutil.WithIDEqual("dropformcont-nav"))
+	if !ok {
+		return Country{}, errors.New("could not find navigation node")
+	}
+
+	countryName, err := scrapeSelectedOption(navNode, "country_id")
+	if err != nil {
+		return Country{}, fmt.Errorf("could not scrape country name: %w", err)
+	}
+
+	breaks, err := scrapeBreakSummaries(navNode)
+	if err != nil {
+		return Country{}, fmt.Errorf("could not scrape surf breaks: %w", err)
+	}
+
+	return Country{
+		Name:   countryName,
+		Breaks: breaks,
+	}, nil
+}
+
+// scrapeSelectedOption returns a text of the selected option of a select node
+// with the given ID.
+func scrapeSelectedOption(n *html.Node, selectID string) (string, error) {
+	selectNode, ok := htmlutil.FindOne(n, htmlutil.WithIDEqual(selectID))
+	if !ok {
+		return "", errors.New("could not find select node")
+	}
+
+	optionNode, ok := htmlutil.FindOne(selectNode, htmlutil.WithAttribute("selected"))
+	if !ok {
+		return "", errors.New("could not find selected option node")
+	}
+
+	optionTextNode := optionNode.FirstChild
+	if optionTextNode == nil {
+		return "", errors.New("could not find selected option text node")
+	}
+
+	return optionTextNode.Data, nil
+}
+
+// scrapeBreakSummaries scrapes surf breaks listed as options of the navigation's
+// surf break select node. The option values hold IDs of the surf breaks.
+func scrapeBreakSummaries(n *html.Node) ([]BreakSummary, error) {
+	breakNode, ok := htmlutil.FindOne(n, htmlutil.WithIDEqual("location_filename_part"))
+	if !ok {
+		return nil, errors.New("could not find surf break node")
+	}
+
+	var breaks []BreakSummary
+	for _, optionNode := range htmlutil.Find(breakNode, htmlutil.WithAttribute("value")) {
+		valueAttr, _ := htmlutil.Attribute(optionNode, "value")
+
+		// Placeholder options (i.e. "Select a break") have no numerical value.
+		id, err := strconv.Atoi(valueAttr.Val)
+		if err != nil {
+			continue
+		}
+
+		optionTextNode := optionNode.FirstChild
+		if optionTextNode == nil {
+			return nil, errors.New("could not find surf break name text node")
+		}
+
+		breaks = append(breaks, BreakSummary{
+			ID:   id,
+			Name: optionTextNode.Data,
+		})
+	}
+
+	return breaks, nil
+}
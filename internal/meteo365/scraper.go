@@ -1,10 +1,13 @@
 package meteo365
 
 import (
+	"log/slog"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/tkuchiki/go-timezone"
+	"github.com/ztimes2/glassy/internal/metrics"
 )
 
 const (
@@ -18,14 +21,169 @@ type Scraper struct {
 	baseURL  string
 	client   *http.Client
 	timezone *timezone.Timezone
+	logger   *slog.Logger
+	metrics  scraperMetrics
+}
+
+// Options holds settings of Scraper. Zero values are replaced with defaults.
+type Options struct {
+	// BaseURL holds the base URL of www.surf-forecast.com.
+	BaseURL string
+
+	// Timeout holds the time limit of each request including its retries.
+	Timeout time.Duration
+
+	// MaxRetries holds how many times a GET request failing with a transient error
+	// is retried. Retries are disabled if it is negative.
+	MaxRetries int
+
+	// CircuitThreshold holds the number of consecutive failed requests after which
+	// requests are rejected with ErrCircuitOpen for CircuitCooldown.
+	CircuitThreshold int
+	CircuitCooldown  time.Duration
+
+	// RateLimit holds how many requests per second are sent on average, and
+	// RateBurst holds how many can be sent at once after a quiet period.
+	RateLimit float64
+	RateBurst int
+
+	// MaxConcurrency holds how many requests can be in flight at once. Requests
+	// over the limits wait for their turn, unless MaxQueue of them are waiting
+	// already, in which case they are rejected with ErrThrottled.
+	MaxConcurrency int
+	MaxQueue       int
+
+	// UserAgent holds the User-Agent header that identifies the requests.
+	UserAgent string
+
+	// RespectRobots enables checking paths against robots.txt of
+	// www.surf-forecast.com. Disallowed requests are rejected with
+	// ErrDisallowedByRobots.
+	RespectRobots bool
+
+	// Logger holds a logger of the requests. slog.Default is used if it is nil.
+	Logger *slog.Logger
+
+	// Metrics holds a registry the scraper's metrics are registered in. The metrics
+	// are not exposed if it is nil.
+	Metrics *metrics.Registry
 }
 
 // NewScraper initializes a new Scraper.
-func NewScraper() *Scraper {
+func NewScraper(opts Options) *Scraper {
+	if opts.BaseURL == "" {
+		opts.BaseURL = defaultBaseURL
+	}
+	if opts.Timeout == 0 {
+		opts.Timeout = defaultTimeout
+	}
+	if opts.MaxRetries == 0 {
+		opts.MaxRetries = defaultMaxRetries
+	}
+	if opts.CircuitThreshold == 0 {
+		opts.CircuitThreshold = defaultCircuitThreshold
+	}
+	if opts.CircuitCooldown == 0 {
+		opts.CircuitCooldown = defaultCircuitCooldown
+	}
+	if opts.RateLimit == 0 {
+		opts.RateLimit = defaultRateLimit
+	}
+	if opts.RateBurst == 0 {
+		opts.RateBurst = defaultRateBurst
+	}
+	if opts.MaxConcurrency == 0 {
+		opts.MaxConcurrency = defaultMaxConcurrency
+	}
+	if opts.MaxQueue == 0 {
+		opts.MaxQueue = defaultMaxQueue
+	}
+	if opts.UserAgent == "" {
+		opts.UserAgent = defaultUserAgent
+	}
+	if opts.Logger == nil {
+		opts.Logger = slog.Default()
+	}
+	if opts.Metrics == nil {
+		opts.Metrics = metrics.NewRegistry()
+	}
+
+	breaker := newCircuitBreaker(opts.CircuitThreshold, opts.CircuitCooldown)
+
+	retries := opts.Metrics.NewCounter(
+		"glassy_upstream_retries_total",
+		"Number of requests to www.surf-forecast.com that were retried after a transient failure.",
+	)
+
+	opts.Metrics.NewGaugeFunc(
+		"glassy_upstream_circuit_open",
+		"Whether requests to www.surf-forecast.com are being rejected after repeated failures.",
+		nil,
+		func() []metrics.Sample {
+			var v float64
+			if breaker.open() {
+				v = 1
+			}
+			return []metrics.Sample{{Value: v}}
+		},
+	)
+
+	limiter := newLimiter(opts.RateLimit, opts.RateBurst, opts.MaxConcurrency, opts.MaxQueue)
+
+	queued := opts.Metrics.NewCounter(
+		"glassy_upstream_queued_total",
+		"Number of requests to www.surf-forecast.com that had to wait for their turn.",
+	)
+	rejected := opts.Metrics.NewCounter(
+		"glassy_upstream_rejected_total",
+		"Number of requests to www.surf-forecast.com that were not sent by reason.",
+		"reason",
+	)
+
+	opts.Metrics.NewGaugeFunc(
+		"glassy_upstream_in_flight",
+		"Number of requests to www.surf-forecast.com in flight.",
+		nil,
+		func() []metrics.Sample {
+			inFlight, _ := limiter.stats()
+			return []metrics.Sample{{Value: float64(inFlight)}}
+		},
+	)
+	opts.Metrics.NewGaugeFunc(
+		"glassy_upstream_queue_length",
+		"Number of requests to www.surf-forecast.com waiting for their turn.",
+		nil,
+		func() []metrics.Sample {
+			_, waiting := limiter.stats()
+			return []metrics.Sample{{Value: float64(waiting)}}
+		},
+	)
+
+	baseURL := strings.TrimSuffix(opts.BaseURL, "/")
+
+	// Every attempt of a request, including its retries, is paced on its own, while
+	// requests that are not sent do not count as failures.
+	polite := &politeTransport{
+		next:       http.DefaultTransport,
+		userAgent:  opts.UserAgent,
+		limiter:    limiter,
+		onQueued:   func() { queued.Inc() },
+		onRejected: func(reason string) { rejected.Inc(reason) },
+	}
+	if opts.RespectRobots {
+		polite.robots = newRobots(baseURL)
+	}
+
 	return &Scraper{
-		baseURL: defaultBaseURL,
+		baseURL: baseURL,
 		client: &http.Client{
-			Timeout: defaultTimeout,
+			Timeout: opts.Timeout,
+			Transport: &transport{
+				next:       polite,
+				maxRetries: max(opts.MaxRetries, 0),
+				breaker:    breaker,
+				onRetry:    func(*http.Request) { retries.Inc() },
+			},
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				// This prevents from automatically following redirects because the BreakSlug method
 				// relies on redirect response which need to be intercepted.
@@ -33,5 +191,7 @@ func NewScraper() *Scraper {
 			},
 		},
 		timezone: timezone.New(),
+		logger:   opts.Logger,
+		metrics:  newScraperMetrics(opts.Metrics),
 	}
 }
@@ -0,0 +1,92 @@
+package meteo365
+
+import (
+	"time"
+)
+
+// Day returns a daily forecast of the given date. Only the date's year, month and
+// day are compared. It returns false if the forecast issue does not cover the date.
+func (f *ForecastIssue) Day(date time.Time) (*DailyForecast, bool) {
+	y, m, d := date.Date()
+	for _, df := range f.Daily {
+		if dy, dm, dd := df.Timestamp.Date(); dy == y && dm == m && dd == d {
+			return df, true
+		}
+	}
+	return nil, false
+}
+
+// Summary aggregates the hourly forecasts of the day into a DailySummary.
+func (f *DailyForecast) Summary() DailySummary {
+	var (
+		s          DailySummary
+		windStates = make(map[string]int)
+	)
+	for i, hf := range f.Hourly {
+		height := hf.Swells.Primary.WaveHeightInMeters
+		if i == 0 || height < s.MinWaveHeightInMeters {
+			s.MinWaveHeightInMeters = height
+		}
+		if height > s.MaxWaveHeightInMeters {
+			s.MaxWaveHeightInMeters = height
+		}
+		if hf.Swells.Primary.PeriodInSeconds > s.MaxPeriodInSeconds {
+			s.MaxPeriodInSeconds = hf.Swells.Primary.PeriodInSeconds
+		}
+		if hf.WaveEnergyInKiloJoules > s.MaxWaveEnergyInKiloJoules {
+			s.MaxWaveEnergyInKiloJoules = hf.WaveEnergyInKiloJoules
+		}
+		if hf.Rating > s.MaxRating {
+			s.MaxRating = hf.Rating
+		}
+		if hf.Wind.SpeedInKilometersPerHour > s.MaxWindSpeedInKilometersPerHour {
+			s.MaxWindSpeedInKilometersPerHour = hf.Wind.SpeedInKilometersPerHour
+		}
+
+		windStates[hf.Wind.State]++
+		if windStates[hf.Wind.State] > windStates[s.PrevailingWindState] {
+			s.PrevailingWindState = hf.Wind.State
+		}
+	}
+	return s
+}
+
+// DailySummary holds aggregated figures of a daily forecast.
+type DailySummary struct {
+	MinWaveHeightInMeters           float64
+	MaxWaveHeightInMeters           float64
+	MaxPeriodInSeconds              float64
+	MaxWaveEnergyInKiloJoules       float64
+	MaxWindSpeedInKilometersPerHour float64
+
+	// MaxRating holds the highest rating of the day. See HourlyForecast.Rating.
+	MaxRating int
+
+	// PrevailingWindState holds the wind state that occurs the most often during the day.
+	PrevailingWindState string
+}
+
+// DescribeWaveHeight returns a surfer's description of a wave height relative to the
+// human body (i.e. "Chest high", "Overhead", etc.).
+func DescribeWaveHeight(meters float64) string {
+	switch {
+	case meters < 0.3:
+		return "Flat"
+	case meters < 0.5:
+		return "Ankle to knee high"
+	case meters < 0.8:
+		return "Knee to waist high"
+	case meters < 1.1:
+		return "Waist to chest high"
+	case meters < 1.4:
+		return "Chest high"
+	case meters < 1.8:
+		return "Head high"
+	case meters < 2.5:
+		return "Overhead"
+	case meters < 3.5:
+		return "Double overhead"
+	default:
+		return "Triple overhead and bigger"
+	}
+}
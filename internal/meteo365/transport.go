@@ -0,0 +1,235 @@
+package meteo365
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen indicates that a request was not sent because www.surf-forecast.com
+// has been failing repeatedly and the scraper is failing fast until it recovers.
+var ErrCircuitOpen = errors.New("circuit open: www.surf-forecast.com is failing")
+
+const (
+	defaultMaxRetries       = 3
+	defaultCircuitThreshold = 5
+	defaultCircuitCooldown  = 30 * time.Second
+
+	// retryBaseDelay is the delay before the first retry, which doubles with every
+	// subsequent one.
+	retryBaseDelay = 250 * time.Millisecond
+
+	// retryMaxDelay caps both the backoff delay and the honoured Retry-After. Requests
+	// asked to be retried later than that are not retried at all.
+	retryMaxDelay = 5 * time.Second
+)
+
+// transport is an http.RoundTripper that retries idempotent requests failing with
+// transient errors using jittered exponential backoff, and stops sending requests for
+// a while once they fail repeatedly.
+type transport struct {
+	next       http.RoundTripper
+	maxRetries int
+	breaker    *circuitBreaker
+
+	// onRetry is called before every retry. It can be nil.
+	onRetry func(req *http.Request)
+
+	// sleep waits before a retry. sleepContext is used if it is nil.
+	sleep func(ctx context.Context, d time.Duration) error
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		ok, trial := t.breaker.allow()
+		if !ok {
+			return nil, ErrCircuitOpen
+		}
+
+		resp, err := t.next.RoundTrip(req)
+
+		// Requests that were not sent, or that the client gave up on, tell nothing
+		// about the health of www.surf-forecast.com.
+		var notSent *notSentError
+		if err != nil && (errors.As(err, &notSent) ||
+			errors.Is(err, context.Canceled) ||
+			errors.Is(req.Context().Err(), context.Canceled)) {
+
+			t.breaker.skip(trial)
+			return nil, err
+		}
+
+		transient := isTransient(resp, err)
+		t.breaker.record(!transient)
+
+		if !transient || attempt == t.maxRetries || !isIdempotent(req) {
+			return resp, err
+		}
+
+		delay := backoff(attempt)
+		if resp != nil {
+			if d, ok := retryAfter(resp); ok {
+				if d > retryMaxDelay {
+					return resp, err
+				}
+				delay = d
+			}
+			resp.Body.Close()
+		}
+
+		if t.onRetry != nil {
+			t.onRetry(req)
+		}
+
+		sleep := t.sleep
+		if sleep == nil {
+			sleep = sleepContext
+		}
+		if err := sleep(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepContext waits for the given duration unless the given context is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// isTransient checks if a request failed in a way that retrying it later might help.
+func isTransient(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+
+		return true
+	}
+	return false
+}
+
+func isIdempotent(req *http.Request) bool {
+	return (req.Method == http.MethodGet || req.Method == http.MethodHead) && req.Body == nil
+}
+
+// backoff returns a random delay before the given retry attempt, which is up to
+// exponentially growing limit (so called full jitter).
+func backoff(attempt int) time.Duration {
+	limit := min(retryBaseDelay<<attempt, retryMaxDelay)
+	return time.Duration(rand.Int64N(int64(limit)) + 1)
+}
+
+// retryAfter parses the Retry-After header, which holds either a number of seconds
+// or an HTTP date.
+func retryAfter(resp *http.Response) (time.Duration, bool) {
+	v := resp.Header.Get("Retry-After")
+	if v == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0), true
+	}
+
+	return 0, false
+}
+
+// circuitBreaker opens after a number of consecutive failures and rejects requests
+// until a cooldown passes. Then it lets a single trial request through, which either
+// closes the circuit or opens it again.
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// allow checks if a request may be sent. It also reports whether the request is the
+// trial one, whose outcome decides whether the circuit closes.
+func (b *circuitBreaker) allow() (ok, trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.openedAt.IsZero() {
+		return true, false
+	}
+	if b.trial || b.now().Sub(b.openedAt) < b.cooldown {
+		return false, false
+	}
+
+	b.trial = true
+	return true, true
+}
+
+// skip gives up on an allowed request without recording its outcome. A trial request
+// that is given up lets another one through.
+func (b *circuitBreaker) skip(trial bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if trial {
+		b.trial = false
+	}
+}
+
+// record records an outcome of a sent request.
+func (b *circuitBreaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if success {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		b.trial = false
+		return
+	}
+
+	b.failures++
+	if b.trial || b.failures >= b.threshold {
+		b.openedAt = b.now()
+		b.trial = false
+	}
+}
+
+// open checks if the circuit is open, i.e. requests are being rejected.
+func (b *circuitBreaker) open() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return !b.openedAt.IsZero()
+}
@@ -0,0 +1,424 @@
+package meteo365
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func newResponse(status int, header http.Header) *http.Response {
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader("")),
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		attempt int
+		limit   time.Duration
+	}{
+		{attempt: 0, limit: 250 * time.Millisecond},
+		{attempt: 1, limit: 500 * time.Millisecond},
+		{attempt: 2, limit: time.Second},
+		{attempt: 4, limit: 4 * time.Second},
+		{attempt: 5, limit: retryMaxDelay},
+		{attempt: 10, limit: retryMaxDelay},
+	}
+
+	for _, tt := range tests {
+		for i := 0; i < 100; i++ {
+			if d := backoff(tt.attempt); d <= 0 || d > tt.limit {
+				t.Fatalf("backoff(%d): expected a delay in (0, %v], got %v", tt.attempt, tt.limit, d)
+			}
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		want   time.Duration
+		slack  time.Duration
+		wantOK bool
+	}{
+		{name: "missing", value: ""},
+		{name: "seconds", value: "3", want: 3 * time.Second, wantOK: true},
+		{name: "zero seconds", value: "0", want: 0, wantOK: true},
+		{name: "negative seconds", value: "-1"},
+		{name: "garbage", value: "soon"},
+		{
+			name:   "future date",
+			value:  time.Now().Add(10 * time.Second).UTC().Format(http.TimeFormat),
+			want:   10 * time.Second,
+			slack:  2 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "past date",
+			value:  time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat),
+			want:   0,
+			wantOK: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := make(http.Header)
+			if tt.value != "" {
+				header.Set("Retry-After", tt.value)
+			}
+
+			got, ok := retryAfter(newResponse(http.StatusServiceUnavailable, header))
+			if ok != tt.wantOK {
+				t.Fatalf("expected ok to be %t, got %t", tt.wantOK, ok)
+			}
+			if got > tt.want || got < tt.want-tt.slack {
+				t.Errorf("expected %v (-%v), got %v", tt.want, tt.slack, got)
+			}
+		})
+	}
+}
+
+func TestTransport_Retries(t *testing.T) {
+	errNetwork := errors.New("connection reset")
+
+	type outcome struct {
+		status     int
+		retryAfter string
+		err        error
+	}
+
+	tests := []struct {
+		name       string
+		method     string
+		maxRetries int
+		outcomes   []outcome
+		wantCalls  int
+		wantStatus int
+		wantErr    error
+		wantDelays []time.Duration
+	}{
+		{
+			name:       "success",
+			outcomes:   []outcome{{status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "transient statuses are retried",
+			outcomes:   []outcome{{status: http.StatusServiceUnavailable}, {status: http.StatusBadGateway}, {status: http.StatusOK}},
+			wantCalls:  3,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "network errors are retried",
+			outcomes:   []outcome{{err: errNetwork}, {status: http.StatusOK}},
+			wantCalls:  2,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "gives up after max retries",
+			maxRetries: 2,
+			outcomes: []outcome{
+				{status: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError},
+				{status: http.StatusInternalServerError},
+				{status: http.StatusOK},
+			},
+			wantCalls:  3,
+			wantStatus: http.StatusInternalServerError,
+		},
+		{
+			name:       "gives up on network errors after max retries",
+			maxRetries: 1,
+			outcomes:   []outcome{{err: errNetwork}, {err: errNetwork}, {status: http.StatusOK}},
+			wantCalls:  2,
+			wantErr:    errNetwork,
+		},
+		{
+			name:       "client errors are not retried",
+			outcomes:   []outcome{{status: http.StatusNotFound}, {status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "non-idempotent requests are not retried",
+			method:     http.MethodPost,
+			outcomes:   []outcome{{status: http.StatusServiceUnavailable}, {status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:       "retry after is honoured",
+			outcomes:   []outcome{{status: http.StatusTooManyRequests, retryAfter: "2"}, {status: http.StatusOK}},
+			wantCalls:  2,
+			wantStatus: http.StatusOK,
+			wantDelays: []time.Duration{2 * time.Second},
+		},
+		{
+			name:       "retry after beyond the max delay is not waited for",
+			outcomes:   []outcome{{status: http.StatusServiceUnavailable, retryAfter: "60"}, {status: http.StatusOK}},
+			wantCalls:  1,
+			wantStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			maxRetries := tt.maxRetries
+			if maxRetries == 0 {
+				maxRetries = defaultMaxRetries
+			}
+
+			var (
+				calls   int
+				retries int
+				delays  []time.Duration
+			)
+			tr := &transport{
+				next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+					o := tt.outcomes[calls]
+					calls++
+
+					if o.err != nil {
+						return nil, o.err
+					}
+
+					header := make(http.Header)
+					if o.retryAfter != "" {
+						header.Set("Retry-After", o.retryAfter)
+					}
+					return newResponse(o.status, header), nil
+				}),
+				maxRetries: maxRetries,
+				breaker:    newCircuitBreaker(100, time.Minute),
+				onRetry:    func(*http.Request) { retries++ },
+				sleep: func(_ context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}
+
+			method := tt.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req, _ := http.NewRequest(method, "https://www.surf-forecast.com/breaks", nil)
+
+			resp, err := tr.RoundTrip(req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+			if tt.wantErr == nil && resp.StatusCode != tt.wantStatus {
+				t.Errorf("expected status %d, got %d", tt.wantStatus, resp.StatusCode)
+			}
+
+			if calls != tt.wantCalls {
+				t.Errorf("expected %d calls, got %d", tt.wantCalls, calls)
+			}
+			if retries != tt.wantCalls-1 || len(delays) != tt.wantCalls-1 {
+				t.Errorf("expected %d retries, got %d with %d delays", tt.wantCalls-1, retries, len(delays))
+			}
+			for i, d := range tt.wantDelays {
+				if i < len(delays) && delays[i] != d {
+					t.Errorf("retry %d: expected a delay of %v, got %v", i, d, delays[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTransport_RetryCanceled(t *testing.T) {
+	tr := &transport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return newResponse(http.StatusServiceUnavailable, nil), nil
+		}),
+		maxRetries: defaultMaxRetries,
+		breaker:    newCircuitBreaker(100, time.Minute),
+		sleep: func(ctx context.Context, d time.Duration) error {
+			return context.Canceled
+		},
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, "https://www.surf-forecast.com/breaks", nil)
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+}
+
+func TestTransport_CancellationsAreNotFailures(t *testing.T) {
+	now := time.Now()
+	breaker := newCircuitBreaker(1, time.Minute)
+	breaker.now = func() time.Time { return now }
+
+	tr := &transport{
+		next: roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			return nil, req.Context().Err()
+		}),
+		breaker: breaker,
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "https://www.surf-forecast.com/breaks", nil)
+
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if breaker.open() {
+		t.Fatal("expected the circuit to stay closed")
+	}
+
+	// A canceled trial request lets another one through.
+	breaker.record(false)
+	now = now.Add(time.Minute)
+
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context canceled, got %v", err)
+	}
+	if ok, trial := breaker.allow(); !ok || !trial {
+		t.Fatalf("expected another trial request to be allowed, got %t, %t", ok, trial)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	type step struct {
+		// advance moves the clock forward before the step.
+		advance time.Duration
+
+		// Either allow is checked, an outcome is recorded, or a trial is skipped.
+		record    bool
+		success   bool
+		skip      bool
+		wantAllow bool
+		wantTrial bool
+		wantOpen  bool
+	}
+
+	allow := func(ok, trial, open bool) step {
+		return step{wantAllow: ok, wantTrial: trial, wantOpen: open}
+	}
+	failure := func(open bool) step {
+		return step{record: true, wantOpen: open}
+	}
+	success := step{record: true, success: true}
+
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "opens after consecutive failures",
+			steps: []step{
+				allow(true, false, false),
+				failure(false),
+				allow(true, false, false),
+				failure(false),
+				allow(true, false, false),
+				failure(true),
+				allow(false, false, true),
+			},
+		},
+		{
+			name: "success resets failures",
+			steps: []step{
+				failure(false),
+				failure(false),
+				success,
+				failure(false),
+				failure(false),
+				allow(true, false, false),
+			},
+		},
+		{
+			name: "lets a single trial through after the cooldown",
+			steps: []step{
+				failure(false),
+				failure(false),
+				failure(true),
+				{advance: 5 * time.Second, wantOpen: true},
+				{advance: 5 * time.Second, wantAllow: true, wantTrial: true, wantOpen: true},
+				allow(false, false, true),
+			},
+		},
+		{
+			name: "successful trial closes the circuit",
+			steps: []step{
+				failure(false),
+				failure(false),
+				failure(true),
+				{advance: 10 * time.Second, wantAllow: true, wantTrial: true, wantOpen: true},
+				success,
+				allow(true, false, false),
+				failure(false),
+			},
+		},
+		{
+			name: "failed trial opens the circuit again",
+			steps: []step{
+				failure(false),
+				failure(false),
+				failure(true),
+				{advance: 10 * time.Second, wantAllow: true, wantTrial: true, wantOpen: true},
+				failure(true),
+				{advance: 9 * time.Second, wantOpen: true},
+				{advance: time.Second, wantAllow: true, wantTrial: true, wantOpen: true},
+			},
+		},
+		{
+			name: "skipped trial lets another one through",
+			steps: []step{
+				failure(false),
+				failure(false),
+				failure(true),
+				{advance: 10 * time.Second, wantAllow: true, wantTrial: true, wantOpen: true},
+				{skip: true, wantOpen: true},
+				allow(true, true, true),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			b := newCircuitBreaker(3, 10*time.Second)
+			b.now = func() time.Time { return now }
+
+			for i, s := range tt.steps {
+				now = now.Add(s.advance)
+
+				switch {
+				case s.record:
+					b.record(s.success)
+				case s.skip:
+					b.skip(true)
+				default:
+					ok, trial := b.allow()
+					if ok != s.wantAllow || trial != s.wantTrial {
+						t.Fatalf("step %d: expected allow to return %t, %t, got %t, %t", i, s.wantAllow, s.wantTrial, ok, trial)
+					}
+				}
+
+				if open := b.open(); open != s.wantOpen {
+					t.Fatalf("step %d: expected open to be %t, got %t", i, s.wantOpen, open)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets holds upper bounds of histogram buckets in seconds suited for
+// measuring latencies of network calls.
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Registry holds metrics and writes them in the Prometheus text exposition format.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// NewRegistry initializes a new Registry.
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, existing := range r.metrics {
+		if existing.name() == m.name() {
+			panic("metrics: duplicate metric " + m.name())
+		}
+	}
+	r.metrics = append(r.metrics, m)
+}
+
+// Write writes all metrics to the given writer in the Prometheus text exposition format.
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	sort.Slice(metrics, func(i, j int) bool {
+		return metrics[i].name() < metrics[j].name()
+	})
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	return bw.Flush()
+}
+
+// Handler returns an HTTP handler that serves the metrics.
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = r.Write(w)
+	})
+}
+
+// desc holds the description shared by all kinds of metrics.
+type desc struct {
+	metricName string
+	help       string
+	labelNames []string
+}
+
+func (d desc) name() string {
+	return d.metricName
+}
+
+func (d desc) writeHeader(w *bufio.Writer, kind string) {
+	w.WriteString("# HELP " + d.metricName + " " + escapeHelp(d.help) + "\n")
+	w.WriteString("# TYPE " + d.metricName + " " + kind + "\n")
+}
+
+func (d desc) checkLabels(values []string) {
+	if len(values) != len(d.labelNames) {
+		panic("metrics: " + d.metricName + " expects " + strconv.Itoa(len(d.labelNames)) + " label values")
+	}
+}
+
+// Counter is a monotonically increasing value partitioned by labels.
+type Counter struct {
+	desc
+	mu     sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+// NewCounter registers a new Counter partitioned by the given label names.
+func (r *Registry) NewCounter(name, help string, labelNames ...string) *Counter {
+	c := &Counter{
+		desc:   desc{metricName: name, help: help, labelNames: labelNames},
+		values: make(map[string]*counterValue),
+	}
+	r.register(c)
+	return c
+}
+
+// Inc increments the counter of the given label values by one.
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add increments the counter of the given label values by the given delta, which
+// must not be negative.
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	c.checkLabels(labelValues)
+	if delta < 0 {
+		panic("metrics: counter " + c.metricName + " cannot decrease")
+	}
+
+	key := labelKey(labelValues)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	v, ok := c.values[key]
+	if !ok {
+		v = &counterValue{labels: slices.Clone(labelValues)}
+		c.values[key] = v
+	}
+	v.value += delta
+}
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.writeHeader(w, "counter")
+	for _, key := range sortedKeys(c.values) {
+		v := c.values[key]
+		writeSample(w, c.metricName, c.labelNames, v.labels, "", "", v.value)
+	}
+}
+
+// Histogram counts observed values in buckets partitioned by labels.
+type Histogram struct {
+	desc
+	buckets []float64
+	mu      sync.Mutex
+	values  map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+// NewHistogram registers a new Histogram with the given upper bounds of buckets
+// partitioned by the given label names.
+func (r *Registry) NewHistogram(name, help string, buckets []float64, labelNames ...string) *Histogram {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+
+	h := &Histogram{
+		desc:    desc{metricName: name, help: help, labelNames: labelNames},
+		buckets: buckets,
+		values:  make(map[string]*histogramValue),
+	}
+	r.register(h)
+	return h
+}
+
+// Observe records a value for the given label values.
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.checkLabels(labelValues)
+
+	key := labelKey(labelValues)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	v, ok := h.values[key]
+	if !ok {
+		v = &histogramValue{
+			labels: slices.Clone(labelValues),
+			counts: make([]uint64, len(h.buckets)),
+		}
+		h.values[key] = v
+	}
+
+	for i, upper := range h.buckets {
+		if value <= upper {
+			v.counts[i]++
+		}
+	}
+	v.count++
+	v.sum += value
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.writeHeader(w, "histogram")
+	for _, key := range sortedKeys(h.values) {
+		v := h.values[key]
+		for i, upper := range h.buckets {
+			writeSample(w, h.metricName+"_bucket", h.labelNames, v.labels, "le", formatFloat(upper), float64(v.counts[i]))
+		}
+		writeSample(w, h.metricName+"_bucket", h.labelNames, v.labels, "le", "+Inf", float64(v.count))
+		writeSample(w, h.metricName+"_sum", h.labelNames, v.labels, "", "", v.sum)
+		writeSample(w, h.metricName+"_count", h.labelNames, v.labels, "", "", float64(v.count))
+	}
+}
+
+// Sample holds a value of a metric collected on demand along with its label values.
+type Sample struct {
+	LabelValues []string
+	Value       float64
+}
+
+// funcMetric is a metric whose samples are collected on demand.
+type funcMetric struct {
+	desc
+	kind    string
+	collect func() []Sample
+}
+
+// NewCounterFunc registers a new counter whose samples are collected by calling the
+// given function whenever the metrics are written.
+func (r *Registry) NewCounterFunc(name, help string, labelNames []string, collect func() []Sample) {
+	r.register(&funcMetric{
+		desc:    desc{metricName: name, help: help, labelNames: labelNames},
+		kind:    "counter",
+		collect: collect,
+	})
+}
+
+// NewGaugeFunc registers a new gauge whose samples are collected by calling the given
+// function whenever the metrics are written.
+func (r *Registry) NewGaugeFunc(name, help string, labelNames []string, collect func() []Sample) {
+	r.register(&funcMetric{
+		desc:    desc{metricName: name, help: help, labelNames: labelNames},
+		kind:    "gauge",
+		collect: collect,
+	})
+}
+
+func (m *funcMetric) write(w *bufio.Writer) {
+	m.writeHeader(w, m.kind)
+	for _, s := range m.collect() {
+		m.checkLabels(s.LabelValues)
+		writeSample(w, m.metricName, m.labelNames, s.LabelValues, "", "", s.Value)
+	}
+}
+
+// writeSample writes a sample line. An extra label such as "le" of histogram buckets
+// is appended to the labels if its name is not empty.
+func writeSample(
+	w *bufio.Writer,
+	name string,
+	labelNames []string,
+	labelValues []string,
+	extraName string,
+	extraValue string,
+	value float64,
+) {
+
+	w.WriteString(name)
+
+	if len(labelNames) > 0 || extraName != "" {
+		w.WriteByte('{')
+		for i, n := range labelNames {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(n + `="` + escapeLabelValue(labelValues[i]) + `"`)
+		}
+		if extraName != "" {
+			if len(labelNames) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extraName + `="` + extraValue + `"`)
+		}
+		w.WriteByte('}')
+	}
+
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(s)
+}
+
+func escapeLabelValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`).Replace(s)
+}
+
+// labelKey joins label values into a map key. The separator cannot occur in valid
+// UTF-8 text.
+func labelKey(values []string) string {
+	return strings.Join(values, "\xff")
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,217 @@
+package router
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/alert"
+	"github.com/ztimes2/glassy/internal/ical"
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/surf"
+)
+
+// defaultCalendarCriteria is used for the calendar feed when no criteria are given
+// in the query.
+var defaultCalendarCriteria = alert.Criteria{
+	MinRating:    4,
+	DaylightOnly: true,
+}
+
+func handleLatestForecastCalendar(service *surf.Service, publicURL string, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("break_id")))
+		if err != nil {
+			writeError(w, r, badRequest("invalid break id"))
+			return
+		}
+
+		criteria, err := parseCriteria(r.URL.Query())
+		if err != nil {
+			writeError(w, r, badRequest(err.Error()))
+			return
+		}
+
+		brk, err := service.Break(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		iss, err := service.LatestForecastIssue(brk)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		forecastURL := publicURL + "/breaks/" + strconv.Itoa(brk.ID) + "/forecasts/latest"
+
+		cal := ical.Calendar{
+			ProductID: "-//glassy//Surf windows//EN",
+			Name:      brk.Name + " surf windows",
+			TimeZone:  timeZoneName(iss.IssuedAt.Location(), iss.IssuedAt),
+			Location:  iss.IssuedAt.Location(),
+		}
+
+		var (
+			windows = alert.FindWindows(iss, criteria, brk.Location)
+			perDay  = make(map[string]int)
+		)
+		for _, win := range windows {
+			day := win.Start.Format(time.DateOnly)
+			perDay[day]++
+
+			cal.Events = append(cal.Events, ical.Event{
+				UID:         windowUID(brk, criteria, day, perDay[day]),
+				Sequence:    int(iss.IssuedAt.Unix() / 3600),
+				Stamp:       iss.IssuedAt,
+				Start:       win.Start,
+				End:         win.End,
+				Summary:     win.Summary(),
+				Description: windowDescription(win, iss) + "\n\n" + forecastURL,
+				URL:         forecastURL,
+			})
+		}
+
+		buf := new(bytes.Buffer)
+		if err := ical.Encode(buf, cal); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		writeCacheable(w, r, buf.Bytes(), iss.IssuedAt, maxAge)
+	}
+}
+
+// parseCriteria parses forecast criteria from query parameters. It returns the default
+// calendar criteria if none of the parameters are given.
+func parseCriteria(q url.Values) (alert.Criteria, error) {
+	keys := []string{"min_rating", "min_height_m", "max_height_m", "min_period_s", "wind", "daylight"}
+
+	given := false
+	for _, k := range keys {
+		if q.Has(k) {
+			given = true
+		}
+	}
+	if !given {
+		return defaultCalendarCriteria, nil
+	}
+
+	var (
+		c   alert.Criteria
+		err error
+	)
+
+	if s := q.Get("min_rating"); s != "" {
+		if c.MinRating, err = strconv.Atoi(s); err != nil {
+			return alert.Criteria{}, errors.New("invalid min rating")
+		}
+	}
+	if s := q.Get("min_height_m"); s != "" {
+		if c.MinWaveHeightInMeters, err = strconv.ParseFloat(s, 64); err != nil {
+			return alert.Criteria{}, errors.New("invalid min height")
+		}
+	}
+	if s := q.Get("max_height_m"); s != "" {
+		if c.MaxWaveHeightInMeters, err = strconv.ParseFloat(s, 64); err != nil {
+			return alert.Criteria{}, errors.New("invalid max height")
+		}
+	}
+	if s := q.Get("min_period_s"); s != "" {
+		if c.MinPeriodInSeconds, err = strconv.ParseFloat(s, 64); err != nil {
+			return alert.Criteria{}, errors.New("invalid min period")
+		}
+	}
+	if s := q.Get("wind"); s != "" {
+		for _, state := range strings.Split(s, ",") {
+			c.WindStates = append(c.WindStates, strings.TrimSpace(state))
+		}
+	}
+	if s := q.Get("daylight"); s != "" {
+		if c.DaylightOnly, err = strconv.ParseBool(s); err != nil {
+			return alert.Criteria{}, errors.New("invalid daylight flag")
+		}
+	}
+
+	if err := c.Validate(); err != nil {
+		return alert.Criteria{}, err
+	}
+
+	return c, nil
+}
+
+// windowUID returns a UID of a calendar event for the n-th window of a day. It does
+// not depend on the window's exact hours, so that the event gets updated rather than
+// duplicated when a newer forecast issue shifts the window. The criteria are spelled
+// out field by field, so that the UID does not change along with the layout of
+// alert.Criteria, and wind states are sorted since their order does not matter.
+func windowUID(b meteo365.Break, c alert.Criteria, day string, n int) string {
+	windStates := slices.Clone(c.WindStates)
+	slices.Sort(windStates)
+
+	fields := []string{
+		strconv.Itoa(b.ID),
+		strconv.FormatFloat(c.MinWaveHeightInMeters, 'f', -1, 64),
+		strconv.FormatFloat(c.MaxWaveHeightInMeters, 'f', -1, 64),
+		strconv.FormatFloat(c.MinPeriodInSeconds, 'f', -1, 64),
+		strings.Join(windStates, ","),
+		strconv.Itoa(c.MinRating),
+		strconv.FormatBool(c.DaylightOnly),
+		day,
+		strconv.Itoa(n),
+	}
+
+	sum := sha1.Sum([]byte(strings.Join(fields, "|")))
+	return hex.EncodeToString(sum[:]) + "@glassy"
+}
+
+// windowDescription returns a textual breakdown of a window's hourly forecasts.
+func windowDescription(win alert.Window, iss *meteo365.ForecastIssue) string {
+	var lines []string
+	for _, hf := range win.Hourly {
+		lines = append(lines, fmt.Sprintf(
+			"%s: %s m, %s s, %s km/h %s, rated %d/10",
+			hf.Timestamp.Format("3 pm"),
+			strconv.FormatFloat(hf.Swells.Primary.WaveHeightInMeters, 'f', -1, 64),
+			strconv.FormatFloat(hf.Swells.Primary.PeriodInSeconds, 'f', -1, 64),
+			strconv.FormatFloat(hf.Wind.SpeedInKilometersPerHour, 'f', -1, 64),
+			hf.Wind.State,
+			hf.Rating,
+		))
+	}
+	lines = append(lines, "Issued "+iss.IssuedAt.Format("Mon 2 Jan, 3 pm MST"))
+	return strings.Join(lines, "\n")
+}
+
+// timeZoneName returns an IANA name of the given location. Fixed offsets that have no
+// name (i.e. "+06") are mapped to the corresponding Etc/GMT zones.
+func timeZoneName(l *time.Location, t time.Time) string {
+	if name := l.String(); strings.Contains(name, "/") || name == "UTC" {
+		return name
+	}
+
+	_, offset := t.In(l).Zone()
+	if offset%3600 != 0 {
+		return ""
+	}
+
+	// The Etc/GMT zones have inverted signs (i.e. Etc/GMT-6 is 6 hours ahead of UTC).
+	hours := -offset / 3600
+	if hours == 0 {
+		return "UTC"
+	}
+	if hours > 0 {
+		return "Etc/GMT+" + strconv.Itoa(hours)
+	}
+	return "Etc/GMT" + strconv.Itoa(hours)
+}
@@ -0,0 +1,261 @@
+package router
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Content codings of compressed responses.
+const (
+	encodingBrotli = "br"
+	encodingGzip   = "gzip"
+)
+
+// compressionMinSize is the size under which responses of known length are not
+// worth compressing.
+const compressionMinSize = 1024
+
+// brotliQuality is the quality of Brotli compression of dynamic responses, which
+// trades a bit of ratio for a lot of speed compared to the best one.
+const brotliQuality = 5
+
+var (
+	gzipWriters = sync.Pool{
+		New: func() any { return gzip.NewWriter(io.Discard) },
+	}
+	brotliWriters = sync.Pool{
+		New: func() any { return brotli.NewWriterLevel(io.Discard, brotliQuality) },
+	}
+)
+
+// withCompression compresses responses with Brotli or gzip, whichever the client
+// prefers. Responses that are already encoded, have no body or are not worth
+// compressing are left as they are.
+func withCompression(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), encodingBrotli, encodingGzip)
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			ifNoneMatch:    r.Header.Get("If-None-Match"),
+		}
+		defer cw.close()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter compresses a response once its headers show that it is worth it.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding    string
+	ifNoneMatch string
+
+	wroteHeader bool
+	// compressor is nil if the response is not compressed.
+	compressor interface {
+		io.WriteCloser
+		Flush() error
+	}
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.wroteHeader = true
+
+	if etag := w.Header().Get("ETag"); status == http.StatusNotModified && strings.Contains(w.ifNoneMatch, "W/"+etag) {
+		// The response revalidates a compressed one, so it has to carry the same
+		// ETag.
+		weakenETag(w.Header())
+	}
+
+	if shouldCompress(status, w.Header()) {
+		h := w.Header()
+		h.Set("Content-Encoding", w.encoding)
+		h.Del("Content-Length")
+		h.Del("Accept-Ranges")
+
+		weakenETag(h)
+
+		switch w.encoding {
+		case encodingBrotli:
+			bw := brotliWriters.Get().(*brotli.Writer)
+			bw.Reset(w.ResponseWriter)
+			w.compressor = bw
+		case encodingGzip:
+			gw := gzipWriters.Get().(*gzip.Writer)
+			gw.Reset(w.ResponseWriter)
+			w.compressor = gw
+		}
+	}
+
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		if w.Header().Get("Content-Type") == "" {
+			// The content type has to be known to decide whether to compress, and
+			// sniffing compressed bytes would be of no use anyway.
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+
+	if w.compressor == nil {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.compressor.Write(b)
+}
+
+// Flush flushes what has been compressed so far to the client.
+func (w *compressWriter) Flush() {
+	if w.compressor != nil {
+		_ = w.compressor.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// close finishes the compressed stream and returns the compressor to its pool.
+func (w *compressWriter) close() {
+	if w.compressor == nil {
+		return
+	}
+
+	_ = w.compressor.Close()
+
+	switch c := w.compressor.(type) {
+	case *brotli.Writer:
+		c.Reset(io.Discard)
+		brotliWriters.Put(c)
+	case *gzip.Writer:
+		c.Reset(io.Discard)
+		gzipWriters.Put(c)
+	}
+	w.compressor = nil
+}
+
+// weakenETag makes the ETag of a compressed response weak, since the response is not
+// byte-for-byte the same as the uncompressed one. Conditional requests still match it,
+// since If-None-Match uses weak comparison.
+func weakenETag(h http.Header) {
+	if etag := h.Get("ETag"); etag != "" && !strings.HasPrefix(etag, "W/") {
+		h.Set("ETag", "W/"+etag)
+	}
+}
+
+// shouldCompress checks if a response with the given status code and headers is worth
+// compressing.
+func shouldCompress(status int, h http.Header) bool {
+	switch {
+	case status < http.StatusOK,
+		status == http.StatusNoContent,
+		status == http.StatusPartialContent,
+		status == http.StatusNotModified:
+
+		return false
+	case h.Get("Content-Encoding") != "":
+		return false
+	}
+
+	if n, err := strconv.Atoi(h.Get("Content-Length")); err == nil && n < compressionMinSize {
+		return false
+	}
+
+	return compressible(h.Get("Content-Type"))
+}
+
+// compressible checks if content of the given type is worth compressing. Images other
+// than SVG and icons, archives and the like are compressed already.
+func compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+
+	switch mediaType {
+	case "application/json",
+		"application/x-ndjson",
+		"application/manifest+json",
+		"application/javascript",
+		"application/xml",
+		"application/atom+xml",
+		"image/svg+xml",
+		"image/x-icon",
+		"image/vnd.microsoft.icon":
+
+		return true
+	}
+	return false
+}
+
+// negotiateEncoding picks the content coding the client prefers out of the given ones
+// according to its Accept-Encoding header. Ties are broken by the order of the given
+// codings. It returns an empty string if the response should not be encoded.
+func negotiateEncoding(acceptEncoding string, codings ...string) string {
+	var (
+		qualities = make(map[string]float64)
+		wildcard  = -1.0
+	)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if coding == "" {
+			continue
+		}
+
+		q := 1.0
+		if name, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(name) == "q" {
+			if v, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = v
+			}
+		}
+
+		if coding == "*" {
+			wildcard = q
+		} else {
+			qualities[coding] = q
+		}
+	}
+
+	var (
+		best        string
+		bestQuality float64
+	)
+	for _, coding := range codings {
+		q, ok := qualities[coding]
+		if !ok {
+			q = wildcard
+		}
+		if q > bestQuality {
+			best, bestQuality = coding, q
+		}
+	}
+	return best
+}
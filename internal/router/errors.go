@@ -0,0 +1,240 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/ztimes2/glassy/internal/alert"
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/ui"
+)
+
+var (
+	// errPageNotFound indicates that nothing is served at the requested path.
+	errPageNotFound = errors.New("page not found")
+
+	// errNoForecast indicates that the latest forecast issue of a surf break has no
+	// days to show.
+	errNoForecast = errors.New("forecast has no days")
+
+	// errUnauthorized indicates that a request lacks a valid API token.
+	errUnauthorized = errors.New("unauthorized")
+
+	// errUnsupportedMediaType indicates that a request body is not JSON.
+	errUnsupportedMediaType = errors.New("unsupported media type")
+)
+
+// requestError indicates that a request is invalid. Its message is shown to users,
+// while its cause, if any, is only logged.
+type requestError struct {
+	message string
+	cause   error
+}
+
+func (e *requestError) Error() string {
+	if e.cause != nil {
+		return e.message + ": " + e.cause.Error()
+	}
+	return e.message
+}
+
+func (e *requestError) Unwrap() error {
+	return e.cause
+}
+
+// badRequest returns an error of an invalid request with the given message.
+func badRequest(message string) error {
+	return &requestError{message: message}
+}
+
+// userError holds what a user is told about an error.
+type userError struct {
+	status  int
+	title   string
+	message string
+}
+
+// describeError tells what a user should be told about an error without revealing
+// its details.
+func describeError(err error) userError {
+	var (
+		reqErr    *requestError
+		parseErr  *meteo365.ParseError
+		statusErr *meteo365.StatusError
+		netErr    net.Error
+	)
+	switch {
+	case errors.As(err, &reqErr):
+		return userError{
+			status:  http.StatusBadRequest,
+			title:   "Invalid request",
+			message: "The link seems to be broken: " + reqErr.message + ".",
+		}
+	case errors.Is(err, meteo365.ErrBreakNotFound):
+		return userError{
+			status:  http.StatusNotFound,
+			title:   "Surf break not found",
+			message: "There is no such surf break on www.surf-forecast.com. It may have been removed, or the link may be broken.",
+		}
+	case errors.Is(err, meteo365.ErrRegionNotFound):
+		return userError{
+			status:  http.StatusNotFound,
+			title:   "Region not found",
+			message: "There is no such region on www.surf-forecast.com. It may have been removed, or the link may be broken.",
+		}
+	case errors.Is(err, meteo365.ErrCountryNotFound):
+		return userError{
+			status:  http.StatusNotFound,
+			title:   "Country not found",
+			message: "There is no such country on www.surf-forecast.com. It may have been removed, or the link may be broken.",
+		}
+	case errors.Is(err, errPageNotFound):
+		return userError{
+			status:  http.StatusNotFound,
+			title:   "Page not found",
+			message: "There is nothing here. The link may be broken.",
+		}
+	case errors.Is(err, alert.ErrRuleNotFound):
+		return userError{
+			status:  http.StatusNotFound,
+			title:   "Alert rule not found",
+			message: "There is no such alert rule. It may have been deleted.",
+		}
+	case errors.Is(err, errUnauthorized):
+		return userError{
+			status:  http.StatusUnauthorized,
+			title:   "Unauthorized",
+			message: "A valid API token is required.",
+		}
+	case errors.Is(err, errUnsupportedMediaType):
+		return userError{
+			status:  http.StatusUnsupportedMediaType,
+			title:   "Unsupported media type",
+			message: "Requests must be sent as application/json.",
+		}
+	case errors.Is(err, alert.ErrTooManyRules):
+		return userError{
+			status:  http.StatusConflict,
+			title:   "Too many alert rules",
+			message: "No more alert rules can be created. Delete some of them first.",
+		}
+	case errors.Is(err, errNoForecast):
+		return userError{
+			status:  http.StatusNotFound,
+			title:   "Forecast not found",
+			message: "www.surf-forecast.com has no forecast for this surf break at the moment. Please try again later.",
+		}
+	case errors.Is(err, meteo365.ErrCircuitOpen):
+		return userError{
+			status:  http.StatusServiceUnavailable,
+			title:   "Forecasts are unavailable",
+			message: "www.surf-forecast.com keeps failing at the moment, so it is given a short break. Please try again in a minute.",
+		}
+	case errors.Is(err, meteo365.ErrThrottled):
+		return userError{
+			status:  http.StatusServiceUnavailable,
+			title:   "Too busy",
+			message: "Too many forecasts are being fetched from www.surf-forecast.com right now. Please try again in a moment.",
+		}
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return userError{
+			status:  http.StatusServiceUnavailable,
+			title:   "Forecasts are unavailable",
+			message: "www.surf-forecast.com took too long to respond. Please try again in a minute.",
+		}
+	case errors.Is(err, meteo365.ErrDisallowedByRobots):
+		return userError{
+			status:  http.StatusServiceUnavailable,
+			title:   "Forecasts are unavailable",
+			message: "www.surf-forecast.com does not allow this page to be fetched at the moment. Please try again later.",
+		}
+	case errors.As(err, &statusErr):
+		return userError{
+			status:  http.StatusServiceUnavailable,
+			title:   "Forecasts are unavailable",
+			message: "www.surf-forecast.com is having trouble at the moment. Please try again in a minute.",
+		}
+	case errors.As(err, &netErr):
+		return userError{
+			status:  http.StatusServiceUnavailable,
+			title:   "Forecasts are unavailable",
+			message: "www.surf-forecast.com could not be reached. Please try again in a minute.",
+		}
+	case errors.As(err, &parseErr):
+		return userError{
+			status:  http.StatusInternalServerError,
+			title:   "Forecast could not be read",
+			message: "www.surf-forecast.com seems to have changed its pages, so they could not be read. Please try again later.",
+		}
+	default:
+		return userError{
+			status:  http.StatusInternalServerError,
+			title:   "Something went wrong",
+			message: "The page could not be shown because of an unexpected error. Please try again later.",
+		}
+	}
+}
+
+// writeError responds with an error page that tells the user what went wrong along
+// with the ID of the request. The details of the error are logged with the request.
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	recordError(r.Context(), err)
+
+	ue := describeError(err)
+
+	page := ui.ErrorPage(ui.ErrorPageProps{
+		StatusCode: ue.status,
+		Title:      ue.title,
+		Message:    ue.message,
+		RequestID:  RequestID(r.Context()),
+		Nonce:      cspNonce(r.Context()),
+		Assets:     pageAssets(r.Context()),
+	})
+
+	h := w.Header()
+	h.Del("ETag")
+	h.Del("Last-Modified")
+	h.Del("Content-Disposition")
+	h.Set("Cache-Control", "no-store")
+
+	buf := new(bytes.Buffer)
+	if err := page.Render(buf); err != nil {
+		http.Error(w, strings.ToLower(http.StatusText(ue.status)), ue.status)
+		return
+	}
+
+	h.Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(ue.status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+// jsonError is the body of a failed JSON response.
+type jsonError struct {
+	Error     string `json:"error"`
+	RequestID string `json:"request_id"`
+}
+
+// writeJSONError responds with a JSON error that tells the client what went wrong
+// along with the ID of the request. The details of the error are logged with the
+// request.
+func writeJSONError(w http.ResponseWriter, r *http.Request, err error) {
+	recordError(r.Context(), err)
+
+	ue := describeError(err)
+
+	message := ue.message
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		message = reqErr.message
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, r, ue.status, jsonError{
+		Error:     message,
+		RequestID: RequestID(r.Context()),
+	})
+}
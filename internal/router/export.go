@@ -0,0 +1,54 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/export"
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// Supported values of the format query parameter of forecast pages.
+const (
+	formatHTML   = "html"
+	formatCSV    = "csv"
+	formatNDJSON = "ndjson"
+)
+
+// writeForecastExport writes the hourly forecasts of a forecast issue in the given
+// export format as an attachment that can be revalidated by clients.
+func writeForecastExport(
+	w http.ResponseWriter,
+	r *http.Request,
+	b meteo365.Break,
+	iss *meteo365.ForecastIssue,
+	format string,
+	maxAge time.Duration,
+) {
+
+	var (
+		buf         = new(bytes.Buffer)
+		contentType string
+		err         error
+	)
+	switch format {
+	case formatCSV:
+		contentType = "text/csv; charset=utf-8"
+		err = export.WriteCSV(buf, iss)
+	case formatNDJSON:
+		contentType = "application/x-ndjson"
+		err = export.WriteNDJSON(buf, iss)
+	}
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	filename := b.Slug + "-" + strconv.FormatInt(iss.IssuedAt.Unix(), 10) + "." + format
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	writeCacheable(w, r, buf.Bytes(), iss.IssuedAt, maxAge)
+}
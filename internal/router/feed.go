@@ -0,0 +1,97 @@
+package router
+
+import (
+	"bytes"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/feed"
+	"github.com/ztimes2/glassy/internal/surf"
+	"github.com/ztimes2/glassy/internal/ui"
+)
+
+// feedEntriesLimit is the maximum number of the most recent forecast issues included
+// in a feed.
+const feedEntriesLimit = 20
+
+func handleForecastFeed(service *surf.Service, publicURL string, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("break_id")))
+		if err != nil {
+			writeError(w, r, badRequest("invalid break id"))
+			return
+		}
+
+		brk, err := service.Break(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// Fetching the latest forecast issue makes sure it is archived before the
+		// history is looked up.
+		latest, err := service.LatestForecastIssue(brk)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// One more issue is needed to compare the oldest entry with.
+		issues, err := service.ForecastHistory(brk, feedEntriesLimit+1)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		var (
+			breakPath   = "/breaks/" + strconv.Itoa(brk.ID)
+			forecastURL = publicURL + breakPath + "/forecasts/latest"
+		)
+
+		f := feed.Feed{
+			ID:           "urn:glassy:breaks:" + strconv.Itoa(brk.ID) + ":forecasts",
+			Title:        brk.Name + " forecasts",
+			Subtitle:     "New forecast issues for " + brk.Name + ", " + brk.CountryName,
+			SelfURL:      publicURL + breakPath + "/forecasts.atom",
+			AlternateURL: forecastURL,
+			Author:       "glassy",
+			Updated:      latest.IssuedAt,
+		}
+
+		// Entries go from the newest to the oldest.
+		for i := 0; i < len(issues) && i < feedEntriesLimit; i++ {
+			props := ui.ForecastIssueEntryProps{
+				ForecastIssue: issues[i],
+			}
+			if i+1 < len(issues) {
+				props.HasPrevious = true
+				props.Changes = surf.CompareIssues(issues[i+1], issues[i])
+			}
+
+			content := new(bytes.Buffer)
+			if err := ui.ForecastIssueEntry(props).Render(content); err != nil {
+				writeError(w, r, err)
+				return
+			}
+
+			f.Entries = append(f.Entries, feed.Entry{
+				ID:      f.ID + ":" + strconv.FormatInt(issues[i].IssuedAt.Unix(), 10),
+				Title:   "Forecast issued " + issues[i].IssuedAt.Format("Mon 2 Jan, 3 pm MST"),
+				URL:     forecastURL,
+				Updated: issues[i].IssuedAt,
+				HTML:    content.String(),
+			})
+		}
+
+		buf := new(bytes.Buffer)
+		if err := feed.EncodeAtom(buf, f); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/atom+xml; charset=utf-8")
+		writeCacheable(w, r, buf.Bytes(), latest.IssuedAt, maxAge)
+	}
+}
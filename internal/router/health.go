@@ -0,0 +1,28 @@
+package router
+
+import (
+	"net/http"
+
+	"github.com/ztimes2/glassy/internal/health"
+)
+
+func handleHealth() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, http.StatusOK, health.Report{Status: health.StatusOK})
+	}
+}
+
+func handleReadiness(checker *health.Checker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		report := checker.Report(r.Context())
+
+		status := http.StatusOK
+		if report.Status != health.StatusOK {
+			status = http.StatusServiceUnavailable
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		writeJSON(w, r, status, report)
+	}
+}
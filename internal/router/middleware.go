@@ -0,0 +1,193 @@
+package router
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/metrics"
+)
+
+// requestIDHeader is a header that carries the ID of a request.
+const requestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// RequestID returns the ID of the request the given context belongs to. It returns an
+// empty string if the context does not belong to a request.
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// withRequestID assigns an ID to every request, stores it in the request's context and
+// echoes it in the X-Request-ID response header. IDs received from clients or proxies
+// are kept if they look sane.
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+
+		w.Header().Set(requestIDHeader, id)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), requestIDKey{}, id)))
+	})
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 64 {
+		return false
+	}
+	for _, c := range id {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+type requestErrorKey struct{}
+
+// recordError records the error a request failed with, so that it is logged along
+// with the request. It does nothing if the context does not belong to a request.
+func recordError(ctx context.Context, err error) {
+	if p, ok := ctx.Value(requestErrorKey{}).(*error); ok {
+		*p = err
+	}
+}
+
+// withAccessLog logs every request once it is served along with the error it failed
+// with if any. Requests that end up with a server error are logged at the error level.
+func withAccessLog(logger *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+
+		var err error
+		next.ServeHTTP(rw, r.WithContext(context.WithValue(r.Context(), requestErrorKey{}, &err)))
+
+		level := slog.LevelInfo
+		if rw.Status() >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		attrs := []slog.Attr{
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rw.Status()),
+			slog.Duration("latency", time.Since(start)),
+			slog.Int64("bytes", rw.bytes),
+			slog.String("request_id", RequestID(r.Context())),
+		}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+
+		logger.LogAttrs(r.Context(), level, "request", attrs...)
+	})
+}
+
+// responseWriter records the status code and the size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *responseWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Status returns the status code of the response.
+func (w *responseWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Unwrap allows http.ResponseController to access the underlying writer.
+func (w *responseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// withMetrics records the duration of every request by its route pattern, which is
+// only known once the request is routed by the given mux.
+func withMetrics(r *metrics.Registry, mux *http.ServeMux) http.Handler {
+	duration := r.NewHistogram(
+		"glassy_http_request_duration_seconds",
+		"Duration of HTTP requests by route pattern and status code.",
+		metrics.DefaultBuckets,
+		"pattern", "status",
+	)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		rw := &responseWriter{ResponseWriter: w}
+
+		mux.ServeHTTP(rw, req)
+
+		pattern := req.Pattern
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+
+		duration.Observe(time.Since(start).Seconds(), pattern, strconv.Itoa(rw.Status()))
+	})
+}
+
+// withAPIToken only lets requests through if they carry the given token as a bearer
+// token in the Authorization header.
+func withAPIToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(got)), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeJSONError(w, r, errUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// withJSONBody only lets requests through if they are sent as application/json.
+// Browsers cannot send such requests to other sites without a CORS preflight, which
+// keeps them from being forged.
+func withJSONBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if err != nil || mediaType != "application/json" {
+			writeJSONError(w, r, errUnsupportedMediaType)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -2,118 +2,591 @@ package router
 
 import (
 	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
-	"io/fs"
+	"fmt"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/ztimes2/glassy/internal/alert"
+	"github.com/ztimes2/glassy/internal/alert/webhook"
+	"github.com/ztimes2/glassy/internal/assets"
+	"github.com/ztimes2/glassy/internal/geo"
+	"github.com/ztimes2/glassy/internal/health"
 	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/metrics"
+	"github.com/ztimes2/glassy/internal/surf"
 	"github.com/ztimes2/glassy/internal/ui"
 )
 
 // New initializes a new HTTP handler configured to serve the application's requests.
-func New(scraper *meteo365.Scraper, assets fs.FS) http.Handler {
+func New(opts Options) (http.Handler, error) {
+	static, err := newStaticFiles(opts.Assets)
+	if err != nil {
+		return nil, fmt.Errorf("could not prepare static files: %w", err)
+	}
+
 	mux := http.NewServeMux()
 
-	mux.HandleFunc("GET /", handleIndex(assets))
-	mux.HandleFunc("GET /search", handleSearch(scraper))
-	mux.HandleFunc("GET /breaks/{break_id}/forecasts/latest", handleLatestForecast(scraper))
+	// Links in calendars and feeds are built from the configured URL rather than the
+	// request's Host header, which cannot be trusted and would end up in cached
+	// responses.
+	publicURL := strings.TrimSuffix(opts.PublicURL, "/")
+
+	mux.HandleFunc("GET /", handleIndex(static))
+	mux.HandleFunc("GET /healthz", handleHealth())
+	mux.HandleFunc("GET /search", handleSearch(opts.Service, opts.CacheMaxAge))
+	mux.HandleFunc("GET /search/nearby", handleSearchNearby(opts.Service))
+	mux.HandleFunc("GET /regions/{region_id}", handleRegion(opts.Service, opts.CacheMaxAge))
+	mux.HandleFunc("GET /countries/{country_id}", handleCountry(opts.Service, opts.CacheMaxAge))
+	mux.HandleFunc("GET /breaks/{break_id}/forecasts/latest", handleLatestForecast(opts.Service, opts.CacheMaxAge))
+	mux.HandleFunc("GET /breaks/{break_id}/forecasts/latest.ics", handleLatestForecastCalendar(opts.Service, publicURL, opts.CacheMaxAge))
+	mux.HandleFunc("GET /breaks/{break_id}/forecasts/history", handleForecastHistory(opts.Service, opts.CacheMaxAge))
+	mux.HandleFunc("GET /breaks/{break_id}/forecasts.atom", handleForecastFeed(opts.Service, publicURL, opts.CacheMaxAge))
+	mux.HandleFunc("GET /breaks/{break_id}/nearby", handleNearbyBreaks(opts.Service, opts.CacheMaxAge))
+	mux.HandleFunc("GET /alerts/rules", handleAlertRules(opts.Rules))
+
+	// Rules can only be changed with the API token, which browsers do not attach
+	// on their own, so the routes are missing unless the token is configured.
+	if opts.AlertsToken != "" {
+		mux.Handle("POST /alerts/rules", withAPIToken(opts.AlertsToken, withJSONBody(handleCreateAlertRule(opts.Service, opts.Rules))))
+		mux.Handle("DELETE /alerts/rules/{rule_id}", withAPIToken(opts.AlertsToken, withJSONBody(handleDeleteAlertRule(opts.Rules))))
+	}
+
+	if opts.Webhooks != nil {
+		mux.HandleFunc("GET /alerts/webhooks/deliveries", handleWebhookDeliveries(opts.Webhooks))
+	}
+
+	if opts.Health != nil {
+		mux.HandleFunc("GET /readyz", handleReadiness(opts.Health))
+	}
+
+	// Browsers remember the header for the whole host, so it is not sent unless the
+	// application is known to be reached over HTTPS.
+	u, err := url.Parse(publicURL)
+	hsts := opts.HSTS && err == nil && u.Scheme == "https"
+
+	logger := opts.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+
+	var handler http.Handler = mux
+	if opts.Metrics != nil {
+		mux.Handle("GET /metrics", opts.Metrics.Handler())
+		handler = withMetrics(opts.Metrics, mux)
+	}
+
+	return withRequestID(withAccessLog(logger, withSecurityHeaders(hsts, withCompression(withAssets(opts.Assets, handler))))), nil
+}
+
+type assetsKey struct{}
 
-	return mux
+// withAssets passes the static files on to the handlers through the context, so that
+// pages, including error pages, link them by their fingerprinted names.
+func withAssets(manifest *assets.Manifest, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), assetsKey{}, manifest)))
+	})
 }
 
-func handleIndex(assets fs.FS) http.HandlerFunc {
+// pageAssets returns the static files that pages of the request the given context
+// belongs to link to.
+func pageAssets(ctx context.Context) *assets.Manifest {
+	manifest, _ := ctx.Value(assetsKey{}).(*assets.Manifest)
+	return manifest
+}
+
+// Options holds dependencies of the HTTP handler.
+type Options struct {
+	Service *surf.Service
+	Rules   *alert.RuleStore
+
+	// AlertsToken holds the token that requests changing alert rules must carry as
+	// a bearer token. Alert rules cannot be changed if it is empty.
+	AlertsToken string
+
+	// Webhooks holds the webhook notifier whose delivery log is exposed. It is nil
+	// if webhooks are not configured.
+	Webhooks *webhook.Notifier
+
+	// PublicURL holds the base URL the application is reached at, which is used for
+	// building absolute links.
+	PublicURL string
+
+	// HSTS holds whether responses carry Strict-Transport-Security. It is only sent
+	// if PublicURL is an https one.
+	HSTS bool
+
+	// Assets holds the static files along with their fingerprinted names.
+	Assets *assets.Manifest
+
+	// CacheMaxAge holds how long clients may cache the responses.
+	CacheMaxAge time.Duration
+
+	// Logger holds a logger of the requests. slog.Default is used if it is nil.
+	Logger *slog.Logger
+
+	// Metrics holds a registry the metrics of the requests are registered in and
+	// exposed from at /metrics. Metrics are disabled if it is nil.
+	Metrics *metrics.Registry
+
+	// Health holds checks that determine whether the application is ready to serve
+	// requests, which are reported at /readyz. Readiness is not reported if it is nil.
+	Health *health.Checker
+}
+
+func handleIndex(static *staticFiles) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if r.URL.Path == "/" {
 			http.Redirect(w, r, "/search", http.StatusMovedPermanently)
 			return
 		}
 
-		http.FileServerFS(assets).ServeHTTP(w, r)
+		static.ServeHTTP(w, r)
 	}
 }
 
-func handleSearch(scraper *meteo365.Scraper) http.HandlerFunc {
+func handleSearch(service *surf.Service, maxAge time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		var (
-			breaks []meteo365.BreakSearchResult
-			err    error
+			results meteo365.SearchResults
+			err     error
 		)
 
 		query := strings.TrimSpace(r.URL.Query().Get("q"))
 		if query != "" {
-			breaks, err = scraper.SearchBreaks(query)
+			results, err = service.Search(query)
 			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
+				writeError(w, r, err)
 				return
 			}
 		}
 
 		page := ui.SearchPage(ui.SearchPageProps{
 			SearchQuery: query,
-			Breaks:      breaks,
+			Results:     results,
+			Nonce:       cspNonce(r.Context()),
+			Assets:      pageAssets(r.Context()),
 		})
 
 		buf := new(bytes.Buffer)
 		if err := page.Render(buf); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeError(w, r, err)
+			return
+		}
+
+		writeCacheable(w, r, buf.Bytes(), time.Time{}, maxAge)
+	}
+}
+
+// nearestBreaksLimit is the maximum number of surf breaks returned when searching
+// for the ones near a user.
+const nearestBreaksLimit = 10
+
+func handleSearchNearby(service *surf.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		lat, err := strconv.ParseFloat(strings.TrimSpace(r.URL.Query().Get("lat")), 64)
+		if err != nil || lat < -90 || lat > 90 {
+			writeError(w, r, badRequest("invalid latitude"))
 			return
 		}
 
-		cacheResponse(w, time.Hour)
+		lon, err := strconv.ParseFloat(strings.TrimSpace(r.URL.Query().Get("lon")), 64)
+		if err != nil || lon < -180 || lon > 180 {
+			writeError(w, r, badRequest("invalid longitude"))
+			return
+		}
+
+		nearest := service.NearestBreaks(geo.Point{
+			Latitude:  lat,
+			Longitude: lon,
+		}, nearestBreaksLimit)
+
+		page := ui.SearchPage(ui.SearchPageProps{
+			NearbySearch: true,
+			NearbyBreaks: nearest,
+			Nonce:        cspNonce(r.Context()),
+			Assets:       pageAssets(r.Context()),
+		})
+
+		buf := new(bytes.Buffer)
+		if err := page.Render(buf); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// The results depend on the user's location and on the surf breaks that
+		// have been stored so far, so they are not worth caching.
+		w.Header().Set("Cache-Control", "no-store")
 		_, _ = w.Write(buf.Bytes())
 	}
 }
 
-func handleLatestForecast(scraper *meteo365.Scraper) http.HandlerFunc {
+func handleRegion(service *surf.Service, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("region_id")))
+		if err != nil {
+			writeError(w, r, badRequest("invalid region id"))
+			return
+		}
+
+		region, err := service.Region(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		page := ui.RegionPage(ui.RegionPageProps{
+			Region: region,
+			Nonce:  cspNonce(r.Context()),
+			Assets: pageAssets(r.Context()),
+		})
+
+		buf := new(bytes.Buffer)
+		if err := page.Render(buf); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		writeCacheable(w, r, buf.Bytes(), time.Time{}, maxAge)
+	}
+}
+
+func handleCountry(service *surf.Service, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("country_id")))
+		if err != nil {
+			writeError(w, r, badRequest("invalid country id"))
+			return
+		}
+
+		country, err := service.Country(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		page := ui.CountryPage(ui.CountryPageProps{
+			Country: country,
+			Nonce:   cspNonce(r.Context()),
+			Assets:  pageAssets(r.Context()),
+		})
+
+		buf := new(bytes.Buffer)
+		if err := page.Render(buf); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		writeCacheable(w, r, buf.Bytes(), time.Time{}, maxAge)
+	}
+}
+
+func handleLatestForecast(service *surf.Service, maxAge time.Duration) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("break_id")))
 		if err != nil {
-			http.Error(w, "invalid break id", http.StatusBadRequest)
+			writeError(w, r, badRequest("invalid break id"))
+			return
+		}
+
+		format := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("format")))
+		switch format {
+		case "":
+			format = formatHTML
+		case formatHTML, formatCSV, formatNDJSON:
+		default:
+			writeError(w, r, badRequest("format must be one of html, csv or ndjson"))
+			return
+		}
+
+		brk, err := service.Break(id)
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
 
-		brk, err := scraper.Break(id)
+		forecast, err := service.LatestForecast(brk)
 		if err != nil {
-			if errors.Is(err, meteo365.ErrBreakNotFound) {
-				http.NotFound(w, r)
+			writeError(w, r, err)
+			return
+		}
+
+		age := maxAge
+		if forecast.Stale {
+			// A stale forecast is cached only briefly so that the refreshed one is
+			// picked up soon.
+			age = min(age, staleMaxAge)
+		}
+
+		if format != formatHTML {
+			writeForecastExport(w, r, brk, forecast.Issue, format, age)
+			return
+		}
+
+		page := ui.LatestForecastPage(ui.LatestForecastPageProps{
+			Break:         brk,
+			ForecastIssue: forecast.Issue,
+			Stale:         forecast.Stale,
+			Nonce:         cspNonce(r.Context()),
+			Assets:        pageAssets(r.Context()),
+		})
+
+		buf := new(bytes.Buffer)
+		if err := page.Render(buf); err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		writeCacheable(w, r, buf.Bytes(), forecast.Issue.IssuedAt, age)
+	}
+}
+
+// historyIssuesLimit is the maximum number of the most recent forecast issues the
+// forecast history of a day is looked up in.
+const historyIssuesLimit = 100
+
+func handleForecastHistory(service *surf.Service, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("break_id")))
+		if err != nil {
+			writeError(w, r, badRequest("invalid break id"))
+			return
+		}
+
+		brk, err := service.Break(id)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		// Fetching the latest forecast issue makes sure it is archived before the
+		// history is looked up.
+		latest, err := service.LatestForecastIssue(brk)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		if len(latest.Daily) == 0 {
+			writeError(w, r, errNoForecast)
+			return
+		}
+
+		dates := make([]time.Time, len(latest.Daily))
+		for i, df := range latest.Daily {
+			dates[i] = df.Timestamp
+		}
+
+		date := dates[0]
+		if s := strings.TrimSpace(r.URL.Query().Get("date")); s != "" {
+			date, err = time.ParseInLocation(time.DateOnly, s, latest.IssuedAt.Location())
+			if err != nil {
+				writeError(w, r, badRequest("invalid date"))
 				return
 			}
+		}
 
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		issues, err := service.ForecastHistory(brk, historyIssuesLimit)
+		if err != nil {
+			writeError(w, r, err)
+			return
+		}
+
+		page := ui.ForecastHistoryPage(ui.ForecastHistoryPageProps{
+			Break:     brk,
+			Dates:     dates,
+			Date:      date,
+			Revisions: surf.DayRevisions(issues, date),
+			Nonce:     cspNonce(r.Context()),
+			Assets:    pageAssets(r.Context()),
+		})
+
+		buf := new(bytes.Buffer)
+		if err := page.Render(buf); err != nil {
+			writeError(w, r, err)
 			return
 		}
 
-		iss, err := scraper.LatestForecastIssue(brk.Slug)
+		writeCacheable(w, r, buf.Bytes(), latest.IssuedAt, maxAge)
+	}
+}
+
+const (
+	defaultNearbyRadiusInKilometers = 50
+	maxNearbyRadiusInKilometers     = 500
+)
+
+func handleNearbyBreaks(service *surf.Service, maxAge time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(strings.TrimSpace(r.PathValue("break_id")))
 		if err != nil {
-			if errors.Is(err, meteo365.ErrBreakNotFound) {
-				http.NotFound(w, r)
+			writeError(w, r, badRequest("invalid break id"))
+			return
+		}
+
+		radius := float64(defaultNearbyRadiusInKilometers)
+		if s := strings.TrimSpace(r.URL.Query().Get("radius_km")); s != "" {
+			radius, err = strconv.ParseFloat(s, 64)
+			if err != nil || radius <= 0 || radius > maxNearbyRadiusInKilometers {
+				writeError(w, r, badRequest("invalid radius"))
 				return
 			}
+		}
+
+		withRatings, _ := strconv.ParseBool(r.URL.Query().Get("ratings"))
 
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		brk, err := service.Break(id)
+		if err != nil {
+			writeError(w, r, err)
 			return
 		}
 
-		page := ui.LatestForecastPage(ui.LatestForecastPageProps{
-			Break:         brk,
-			ForecastIssue: iss,
+		nearby := service.NearbyBreaks(brk, radius, withRatings)
+
+		list := ui.NearbyBreaks(ui.NearbyBreaksProps{
+			RadiusInKilometers: radius,
+			Breaks:             nearby,
 		})
 
 		buf := new(bytes.Buffer)
-		if err := page.Render(buf); err != nil {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+		if err := list.Render(buf); err != nil {
+			writeError(w, r, err)
 			return
 		}
 
-		cacheResponse(w, time.Hour)
-		_, _ = w.Write(buf.Bytes())
+		writeCacheable(w, r, buf.Bytes(), time.Time{}, maxAge)
 	}
 }
 
-func cacheResponse(w http.ResponseWriter, d time.Duration) {
-	age := strconv.Itoa(int(d.Seconds()))
-	w.Header().Set("Cache-Control", "max-age="+age)
+func handleAlertRules(rules *alert.RuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, rules.Rules())
+	}
+}
+
+// maxRuleSize is the maximum size of a request body of a new rule.
+const maxRuleSize = 16 << 10
+
+func handleCreateAlertRule(service *surf.Service, rules *alert.RuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule alert.Rule
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxRuleSize)).Decode(&rule); err != nil {
+			writeJSONError(w, r, &requestError{message: "invalid rule", cause: err})
+			return
+		}
+
+		if err := rule.Validate(); err != nil {
+			writeJSONError(w, r, badRequest(err.Error()))
+			return
+		}
+
+		if _, err := service.Break(rule.BreakID); err != nil {
+			if errors.Is(err, meteo365.ErrBreakNotFound) {
+				writeJSONError(w, r, badRequest("surf break not found"))
+				return
+			}
+
+			writeJSONError(w, r, err)
+			return
+		}
+
+		rule, err := rules.Create(rule)
+		if err != nil {
+			writeJSONError(w, r, err)
+			return
+		}
+
+		writeJSON(w, r, http.StatusCreated, rule)
+	}
+}
+
+func handleDeleteAlertRule(rules *alert.RuleStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := rules.Delete(r.PathValue("rule_id")); err != nil {
+			writeJSONError(w, r, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func handleWebhookDeliveries(webhooks *webhook.Notifier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, r, http.StatusOK, webhooks.Deliveries())
+	}
+}
+
+func writeJSON(w http.ResponseWriter, r *http.Request, status int, v any) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		writeJSONError(w, r, fmt.Errorf("could not marshal response: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(b)
+}
+
+const (
+	// staleMaxAge is how long clients may cache a stale forecast.
+	staleMaxAge = time.Minute
+
+	// staleIfError is how long clients may keep using a cached response while the
+	// web server fails.
+	staleIfError = 24 * time.Hour
+)
+
+// writeCacheable writes a response body that clients may cache for the given
+// duration. Its ETag is computed from the body, and Last-Modified is set unless the
+// given time is zero, so that clients can revalidate the response with If-None-Match
+// or If-Modified-Since and get 304 Not Modified if it has not changed.
+//
+// Bodies of pages differ by the nonce of every request, so the ETag is computed
+// without the nonce and is weak in that case. Such responses must not be shared
+// between clients either, since the nonce would stop being secret.
+func writeCacheable(w http.ResponseWriter, r *http.Request, body []byte, lastModified time.Time, maxAge time.Duration) {
+	nonce := cspNonce(r.Context())
+	hasNonce := nonce != "" && bytes.Contains(body, []byte(nonce))
+
+	sum := sha256.Sum256(withoutNonce(body, nonce))
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	if hasNonce {
+		etag = "W/" + etag
+	}
+
+	w.Header().Set("ETag", etag)
+	cacheResponse(w, maxAge, hasNonce)
+
+	http.ServeContent(keepPolicyWriter{w}, r, "", lastModified, bytes.NewReader(body))
+}
+
+// cacheResponse lets clients cache a response for the given duration, and then keep
+// using it for as long again while they revalidate it in the background. Private
+// responses are only cached by clients themselves rather than by shared caches.
+func cacheResponse(w http.ResponseWriter, d time.Duration, private bool) {
+	directives := []string{"max-age=" + strconv.Itoa(int(d.Seconds()))}
+	if private {
+		directives = append([]string{"private"}, directives...)
+	}
+
+	if d > 0 {
+		directives = append(
+			directives,
+			"stale-while-revalidate="+strconv.Itoa(int(d.Seconds())),
+			"stale-if-error="+strconv.Itoa(int(staleIfError.Seconds())),
+		)
+	}
+
+	w.Header().Set("Cache-Control", strings.Join(directives, ", "))
 }
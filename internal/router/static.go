@@ -0,0 +1,180 @@
+package router
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andybalholm/brotli"
+	"github.com/ztimes2/glassy/internal/assets"
+)
+
+// staticContentTypes holds content types of static files by extensions that are not
+// known to the mime package.
+var staticContentTypes = map[string]string{
+	".webmanifest": "application/manifest+json",
+}
+
+// staticFiles serves static files. Compressible files are compressed with the best
+// compression once, when the application starts, and served pre-compressed in the
+// encoding the client prefers.
+//
+// Files are served by their names as well as by their fingerprinted names, which can
+// be cached by clients forever.
+type staticFiles struct {
+	assets     fs.FS
+	manifest   *assets.Manifest
+	fileServer http.Handler
+
+	// files holds the regular static files by their names. It is not modified after
+	// initialization, so it is safe for concurrent use.
+	files map[string]*staticFile
+}
+
+// staticFile holds the content of a static file in every encoding it is served in.
+type staticFile struct {
+	contentType string
+	etag        string
+
+	// encoded holds the compressed content by content coding. It is empty if the
+	// file is not worth compressing.
+	encoded  map[string][]byte
+	identity []byte
+}
+
+// newStaticFiles loads and compresses every regular static file of the given
+// manifest.
+func newStaticFiles(manifest *assets.Manifest) (*staticFiles, error) {
+	files := make(map[string]*staticFile)
+
+	err := fs.WalkDir(manifest.FS(), ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		content, err := fs.ReadFile(manifest.FS(), name)
+		if err != nil {
+			return fmt.Errorf("could not read %s: %w", name, err)
+		}
+
+		f, err := newStaticFile(name, content)
+		if err != nil {
+			return fmt.Errorf("could not compress %s: %w", name, err)
+		}
+
+		files[name] = f
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &staticFiles{
+		assets:     manifest.FS(),
+		manifest:   manifest,
+		fileServer: http.FileServerFS(manifest.FS()),
+		files:      files,
+	}, nil
+}
+
+// immutableMaxAge is how long clients may cache files served by their fingerprinted
+// names, which is a year as recommended for the immutable directive.
+const immutableMaxAge = 365 * 24 * time.Hour
+
+func (s *staticFiles) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+
+	if original, ok := s.manifest.Resolve(name); ok {
+		name = original
+		w.Header().Set("Cache-Control", "public, max-age="+strconv.Itoa(int(immutableMaxAge.Seconds()))+", immutable")
+	}
+
+	f, ok := s.files[name]
+	if !ok {
+		if _, err := fs.Stat(s.assets, name); errors.Is(err, fs.ErrNotExist) {
+			writeError(w, r, errPageNotFound)
+			return
+		}
+
+		// Directories and the like are left to the file server.
+		s.fileServer.ServeHTTP(w, r)
+		return
+	}
+
+	w.Header().Set("Content-Type", f.contentType)
+
+	content, etag := f.identity, f.etag
+	if len(f.encoded) > 0 {
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), encodingBrotli, encodingGzip)
+		if encoding != "" {
+			w.Header().Set("Content-Encoding", encoding)
+			content = f.encoded[encoding]
+			etag = strings.TrimSuffix(f.etag, `"`) + "-" + encoding + `"`
+		}
+	}
+	w.Header().Set("ETag", etag)
+
+	http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(content))
+}
+
+func newStaticFile(name string, content []byte) (*staticFile, error) {
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = staticContentTypes[path.Ext(name)]
+	}
+	if contentType == "" {
+		contentType = http.DetectContentType(content)
+	}
+
+	sum := sha256.Sum256(content)
+
+	f := &staticFile{
+		contentType: contentType,
+		etag:        `"` + hex.EncodeToString(sum[:16]) + `"`,
+		identity:    content,
+	}
+
+	if len(content) < compressionMinSize || !compressible(contentType) {
+		return f, nil
+	}
+
+	gz := new(bytes.Buffer)
+	gw, err := gzip.NewWriterLevel(gz, gzip.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := gw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, err
+	}
+
+	br := new(bytes.Buffer)
+	bw := brotli.NewWriterLevel(br, brotli.BestCompression)
+	if _, err := bw.Write(content); err != nil {
+		return nil, err
+	}
+	if err := bw.Close(); err != nil {
+		return nil, err
+	}
+
+	f.encoded = map[string][]byte{
+		encodingGzip:   gz.Bytes(),
+		encodingBrotli: br.Bytes(),
+	}
+	return f, nil
+}
@@ -0,0 +1,73 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// BreakStore is a local store of surf breaks that persists them as a JSON file.
+// It is safe for concurrent use.
+type BreakStore struct {
+	path   string
+	mu     sync.RWMutex
+	breaks map[int]meteo365.Break
+}
+
+// OpenBreakStore initializes a new BreakStore that is backed by the file at the given
+// path. The file is created on the first write if it does not exist yet.
+func OpenBreakStore(path string) (*BreakStore, error) {
+	s := &BreakStore{
+		path:   path,
+		breaks: make(map[int]meteo365.Break),
+	}
+
+	var breaks []meteo365.Break
+	if _, err := ReadJSON(path, &breaks); err != nil {
+		return nil, err
+	}
+
+	for _, brk := range breaks {
+		s.breaks[brk.ID] = brk
+	}
+
+	return s, nil
+}
+
+// Put stores a surf break replacing the existing one with the same ID if any.
+func (s *BreakStore) Put(b meteo365.Break) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.breaks[b.ID] = b
+
+	if err := WriteJSON(s.path, s.sorted()); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	return nil
+}
+
+// All returns all the stored surf breaks sorted by their IDs.
+func (s *BreakStore) All() []meteo365.Break {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.sorted()
+}
+
+// sorted returns the stored surf breaks sorted by their IDs. The caller must hold the lock.
+func (s *BreakStore) sorted() []meteo365.Break {
+	breaks := make([]meteo365.Break, 0, len(s.breaks))
+	for _, b := range s.breaks {
+		breaks = append(breaks, b)
+	}
+
+	sort.Slice(breaks, func(i, j int) bool {
+		return breaks[i].ID < breaks[j].ID
+	})
+
+	return breaks
+}
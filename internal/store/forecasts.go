@@ -0,0 +1,188 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// ForecastArchive is a local archive of forecast issues that persists each distinct
+// issue of a surf break as a separate JSON file named after its issue timestamp.
+// It is safe for concurrent use.
+type ForecastArchive struct {
+	dir       string
+	retention time.Duration
+	now       func() time.Time
+	mu        sync.RWMutex
+}
+
+// OpenForecastArchive initializes a new ForecastArchive that is backed by the given
+// directory and keeps forecast issues for the given retention period. The directory
+// is created on the first write if it does not exist yet.
+func OpenForecastArchive(dir string, retention time.Duration) *ForecastArchive {
+	return &ForecastArchive{
+		dir:       dir,
+		retention: retention,
+		now:       time.Now,
+	}
+}
+
+// Put archives a forecast issue of a surf break. Issues that have already been
+// archived are left intact, and issues of the surf break that were issued before the
+// retention period are removed.
+func (a *ForecastArchive) Put(breakID int, iss *meteo365.ForecastIssue) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	path := a.issuePath(breakID, iss)
+
+	_, err := os.Stat(path)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("could not stat file: %w", err)
+	}
+
+	if err := WriteJSON(path, iss); err != nil {
+		return fmt.Errorf("could not write file: %w", err)
+	}
+
+	if err := a.removeExpired(breakID); err != nil {
+		return fmt.Errorf("could not remove expired issues: %w", err)
+	}
+
+	return nil
+}
+
+// removeExpired removes the files of a surf break's forecast issues that were issued
+// before the retention period. The most recent issue is always kept so that there is
+// a stale forecast to fall back on. The caller must hold the lock.
+func (a *ForecastArchive) removeExpired(breakID int) error {
+	files, err := a.issueFiles(breakID)
+	if err != nil || len(files) == 0 {
+		return err
+	}
+
+	cutoff := a.now().Add(-a.retention).Unix()
+	for _, f := range files[:len(files)-1] {
+		if f.issuedAt >= cutoff {
+			continue
+		}
+		if err := os.Remove(filepath.Join(a.breakDir(breakID), f.name)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("could not remove file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Issues returns up to the given number of the most recently issued archived forecast
+// issues of a surf break sorted by their issue timestamps from the newest to the
+// oldest. Files that cannot be read are skipped and logged.
+func (a *ForecastArchive) Issues(breakID int, limit int) ([]*meteo365.ForecastIssue, error) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	files, err := a.issueFiles(breakID)
+	if err != nil {
+		return nil, err
+	}
+
+	var issues []*meteo365.ForecastIssue
+	for i := len(files) - 1; i >= 0 && len(issues) < limit; i-- {
+		iss, err := a.readIssue(breakID, files[i].name)
+		if err != nil {
+			slog.Warn(
+				"could not read archived forecast issue",
+				"break_id", breakID,
+				"file", files[i].name,
+				"error", err,
+			)
+			continue
+		}
+		issues = append(issues, iss)
+	}
+
+	return issues, nil
+}
+
+// Latest returns the most recently issued archived forecast issue of a surf break.
+// It returns false if no forecast issues of the surf break are archived. Files that
+// cannot be read are skipped and logged.
+func (a *ForecastArchive) Latest(breakID int) (*meteo365.ForecastIssue, bool, error) {
+	issues, err := a.Issues(breakID, 1)
+	if err != nil || len(issues) == 0 {
+		return nil, false, err
+	}
+
+	return issues[0], true, nil
+}
+
+// issueFile is a file of an archived forecast issue.
+type issueFile struct {
+	name     string
+	issuedAt int64
+}
+
+// issueFiles returns the files of a surf break's archived forecast issues sorted by
+// their issue timestamps from the oldest to the newest. The caller must hold the lock.
+func (a *ForecastArchive) issueFiles(breakID int) ([]issueFile, error) {
+	entries, err := os.ReadDir(a.breakDir(breakID))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("could not read directory: %w", err)
+	}
+
+	var files []issueFile
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+
+		issuedAt, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		files = append(files, issueFile{
+			name:     e.Name(),
+			issuedAt: issuedAt,
+		})
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return files[i].issuedAt < files[j].issuedAt
+	})
+
+	return files, nil
+}
+
+func (a *ForecastArchive) readIssue(breakID int, name string) (*meteo365.ForecastIssue, error) {
+	var iss meteo365.ForecastIssue
+	if _, err := ReadJSON(filepath.Join(a.breakDir(breakID), name), &iss); err != nil {
+		return nil, fmt.Errorf("could not read issue %q: %w", name, err)
+	}
+	return &iss, nil
+}
+
+func (a *ForecastArchive) breakDir(breakID int) string {
+	return filepath.Join(a.dir, strconv.Itoa(breakID))
+}
+
+func (a *ForecastArchive) issuePath(breakID int, iss *meteo365.ForecastIssue) string {
+	return filepath.Join(a.breakDir(breakID), strconv.FormatInt(iss.IssuedAt.Unix(), 10)+".json")
+}
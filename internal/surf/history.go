@@ -0,0 +1,81 @@
+package surf
+
+import (
+	"time"
+
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// ForecastHistory returns up to the given number of the most recently issued archived
+// forecast issues of a surf break sorted from the newest to the oldest.
+func (s *Service) ForecastHistory(b meteo365.Break, limit int) ([]*meteo365.ForecastIssue, error) {
+	return s.archive.Issues(b.ID, limit)
+}
+
+// DayRevisions returns how the forecast of the given date evolved across the given
+// forecast issues sorted from the newest to the oldest. Revisions go from the oldest
+// to the newest, and issues that do not cover the date are skipped.
+func DayRevisions(issues []*meteo365.ForecastIssue, date time.Time) []DayRevision {
+	var (
+		revisions []DayRevision
+		previous  *meteo365.DailySummary
+	)
+	for i := len(issues) - 1; i >= 0; i-- {
+		iss := issues[i]
+		df, ok := iss.Day(date)
+		if !ok {
+			continue
+		}
+
+		summary := df.Summary()
+		revisions = append(revisions, DayRevision{
+			IssuedAt: iss.IssuedAt,
+			Summary:  summary,
+			Previous: previous,
+		})
+		previous = &summary
+	}
+	return revisions
+}
+
+// DayRevision holds a summary of a day's forecast as of a single forecast issue.
+type DayRevision struct {
+	IssuedAt time.Time
+	Summary  meteo365.DailySummary
+
+	// Previous holds a summary of the same day as of the preceding forecast issue.
+	// It is nil for the earliest revision.
+	Previous *meteo365.DailySummary
+}
+
+// CompareIssues returns the changes of the daily forecasts that are covered by both the
+// previous and the current forecast issues. Days whose summaries did not change are
+// omitted.
+func CompareIssues(previous, current *meteo365.ForecastIssue) []DayChange {
+	var changes []DayChange
+	for _, df := range current.Daily {
+		prev, ok := previous.Day(df.Timestamp)
+		if !ok {
+			continue
+		}
+
+		cur, old := df.Summary(), prev.Summary()
+		if cur == old {
+			continue
+		}
+
+		changes = append(changes, DayChange{
+			Date:     df.Timestamp,
+			Previous: old,
+			Current:  cur,
+		})
+	}
+	return changes
+}
+
+// DayChange holds summaries of a day's forecast as of two subsequent forecast issues.
+type DayChange struct {
+	Date     time.Time
+	Previous meteo365.DailySummary
+	Current  meteo365.DailySummary
+}
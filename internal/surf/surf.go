@@ -0,0 +1,427 @@
+package surf
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ztimes2/glassy/internal/cache"
+	"github.com/ztimes2/glassy/internal/geo"
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/metrics"
+	"github.com/ztimes2/glassy/internal/store"
+)
+
+// nearbyConcurrency is the maximum number of surf breaks whose forecasts are fetched
+// simultaneously when rating nearby surf breaks.
+const nearbyConcurrency = 4
+
+const (
+	// refreshAttempts is how many times a stale forecast is scraped again in the
+	// background before giving up until it is requested again.
+	refreshAttempts = 5
+
+	// refreshDelay is the delay before the first background attempt, which doubles
+	// after each failed one.
+	refreshDelay = 15 * time.Second
+)
+
+// Service provides surf breaks and their forecasts by scraping www.surf-forecast.com
+// and caching the results.
+type Service struct {
+	scraper    *meteo365.Scraper
+	breakStore *store.BreakStore
+	archive    *store.ForecastArchive
+	countries  *cache.Cache[int, meteo365.Country]
+	regions    *cache.Cache[int, meteo365.Region]
+	breaks     *cache.Cache[int, meteo365.Break]
+	forecasts  *cache.Cache[string, *meteo365.ForecastIssue]
+
+	mu sync.Mutex
+	// ctx is the context of Run, which stops the background refreshes. It is nil
+	// unless Run is running.
+	ctx context.Context
+	// refreshing holds slugs of surf breaks whose stale forecasts are being scraped
+	// in the background.
+	refreshing map[string]struct{}
+	refreshes  sync.WaitGroup
+}
+
+// NewService initializes a new Service. Every scraped surf break is recorded in the
+// given store so that it can be looked up by its location later on, and every scraped
+// forecast issue is recorded in the given archive to keep track of its history. The
+// scraped data is reused for the given cache TTL before being scraped again.
+func NewService(
+	scraper *meteo365.Scraper,
+	breakStore *store.BreakStore,
+	archive *store.ForecastArchive,
+	cacheTTL time.Duration,
+) *Service {
+
+	return &Service{
+		scraper:    scraper,
+		breakStore: breakStore,
+		archive:    archive,
+		countries:  cache.New[int, meteo365.Country](cacheTTL),
+		regions:    cache.New[int, meteo365.Region](cacheTTL),
+		breaks:     cache.New[int, meteo365.Break](cacheTTL),
+		forecasts:  cache.New[string, *meteo365.ForecastIssue](cacheTTL),
+		refreshing: make(map[string]struct{}),
+	}
+}
+
+// RegisterMetrics registers metrics of the service's caches in the given registry.
+func (s *Service) RegisterMetrics(r *metrics.Registry) {
+	caches := []struct {
+		name  string
+		stats func() cache.Stats
+	}{
+		{"countries", s.countries.Stats},
+		{"regions", s.regions.Stats},
+		{"breaks", s.breaks.Stats},
+		{"forecasts", s.forecasts.Stats},
+	}
+
+	r.NewCounterFunc(
+		"glassy_cache_lookups_total",
+		"Number of cache lookups by cache and result.",
+		[]string{"cache", "result"},
+		func() []metrics.Sample {
+			var samples []metrics.Sample
+			for _, c := range caches {
+				st := c.stats()
+				samples = append(
+					samples,
+					metrics.Sample{LabelValues: []string{c.name, "hit"}, Value: float64(st.Hits)},
+					metrics.Sample{LabelValues: []string{c.name, "miss"}, Value: float64(st.Misses)},
+				)
+			}
+			return samples
+		},
+	)
+
+	r.NewGaugeFunc(
+		"glassy_cache_hit_ratio",
+		"Ratio of cache lookups that were hits since the start by cache.",
+		[]string{"cache"},
+		func() []metrics.Sample {
+			var samples []metrics.Sample
+			for _, c := range caches {
+				st := c.stats()
+
+				var ratio float64
+				if total := st.Hits + st.Misses; total > 0 {
+					ratio = float64(st.Hits) / float64(total)
+				}
+
+				samples = append(samples, metrics.Sample{LabelValues: []string{c.name}, Value: ratio})
+			}
+			return samples
+		},
+	)
+}
+
+// Run allows stale forecasts to be scraped again in the background until the given
+// context is canceled, and then waits for the background refreshes to stop. Stale
+// forecasts are not refreshed in the background unless it runs.
+func (s *Service) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.ctx = ctx
+	s.mu.Unlock()
+
+	<-ctx.Done()
+
+	// No more refreshes can be started from now on.
+	s.mu.Lock()
+	s.ctx = nil
+	s.mu.Unlock()
+
+	s.refreshes.Wait()
+}
+
+// Search searches for surf breaks, regions and countries using a text query.
+func (s *Service) Search(query string) (meteo365.SearchResults, error) {
+	return s.scraper.Search(query)
+}
+
+// Region returns a region by its ID. It returns meteo365.ErrRegionNotFound for
+// non-existent regions.
+func (s *Service) Region(id int) (meteo365.Region, error) {
+	if r, ok := s.regions.Get(id); ok {
+		return r, nil
+	}
+
+	r, err := s.scraper.Region(id)
+	if err != nil {
+		return meteo365.Region{}, err
+	}
+
+	s.regions.Set(id, r)
+	return r, nil
+}
+
+// Country returns a country by its ID. It returns meteo365.ErrCountryNotFound for
+// non-existent countries.
+func (s *Service) Country(id int) (meteo365.Country, error) {
+	if c, ok := s.countries.Get(id); ok {
+		return c, nil
+	}
+
+	c, err := s.scraper.Country(id)
+	if err != nil {
+		return meteo365.Country{}, err
+	}
+
+	s.countries.Set(id, c)
+	return c, nil
+}
+
+// Break returns a surf break by its ID. It returns meteo365.ErrBreakNotFound for
+// non-existent surf breaks.
+func (s *Service) Break(id int) (meteo365.Break, error) {
+	if b, ok := s.breaks.Get(id); ok {
+		return b, nil
+	}
+
+	b, err := s.scraper.Break(id)
+	if err != nil {
+		return meteo365.Break{}, err
+	}
+
+	// The surf break is only stored for looking it up by its location later on, so
+	// failing to store it is not worth failing the request.
+	if err := s.breakStore.Put(b); err != nil {
+		slog.Warn("could not store surf break", "break_id", b.ID, "error", err)
+	}
+
+	s.breaks.Set(id, b)
+	return b, nil
+}
+
+// LatestForecastIssue returns the latest forecast issue of a surf break. It returns
+// meteo365.ErrBreakNotFound for non-existent surf breaks. Unlike LatestForecast, it
+// never returns a stale forecast issue.
+func (s *Service) LatestForecastIssue(b meteo365.Break) (*meteo365.ForecastIssue, error) {
+	if iss, ok := s.forecasts.Get(b.Slug); ok {
+		return iss, nil
+	}
+	return s.scrapeForecast(b)
+}
+
+// LatestForecast holds the latest forecast issue of a surf break.
+type LatestForecast struct {
+	Issue *meteo365.ForecastIssue
+
+	// Stale is true if the forecast could not be scraped and the latest archived
+	// forecast issue is served instead.
+	Stale bool
+}
+
+// LatestForecast returns the latest forecast issue of a surf break. It returns
+// meteo365.ErrBreakNotFound for non-existent surf breaks.
+//
+// If the forecast cannot be scraped, the latest archived forecast issue is returned
+// as stale if any, and the forecast is scraped again in the background. Until that
+// succeeds, the stale forecast issue is returned right away.
+func (s *Service) LatestForecast(b meteo365.Break) (LatestForecast, error) {
+	if iss, ok := s.forecasts.Get(b.Slug); ok {
+		return LatestForecast{Issue: iss}, nil
+	}
+
+	if s.isRefreshing(b.Slug) {
+		if archived, ok, _ := s.archive.Latest(b.ID); ok {
+			return LatestForecast{Issue: archived, Stale: true}, nil
+		}
+	}
+
+	iss, err := s.scrapeForecast(b)
+	if err != nil {
+		// A stale forecast is better than none.
+		if !errors.Is(err, meteo365.ErrBreakNotFound) {
+			if archived, ok, _ := s.archive.Latest(b.ID); ok {
+				s.refreshInBackground(b)
+				return LatestForecast{Issue: archived, Stale: true}, nil
+			}
+		}
+		return LatestForecast{}, err
+	}
+
+	return LatestForecast{Issue: iss}, nil
+}
+
+// scrapeForecast scrapes the latest forecast issue of a surf break, and archives and
+// caches it.
+func (s *Service) scrapeForecast(b meteo365.Break) (*meteo365.ForecastIssue, error) {
+	iss, err := s.scraper.LatestForecastIssue(b.Slug)
+	if err != nil {
+		return nil, err
+	}
+
+	// The forecast issue is still served if it cannot be archived.
+	if err := s.archive.Put(b.ID, iss); err != nil {
+		slog.Warn("could not archive forecast issue", "break_id", b.ID, "error", err)
+	}
+
+	s.forecasts.Set(b.Slug, iss)
+	return iss, nil
+}
+
+func (s *Service) isRefreshing(slug string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.refreshing[slug]
+	return ok
+}
+
+// refreshInBackground scrapes the forecast of a surf break in the background with
+// growing delays until it succeeds, runs out of attempts or Run stops. It does nothing
+// if the forecast is being refreshed already.
+func (s *Service) refreshInBackground(b meteo365.Break) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx == nil {
+		return
+	}
+	if _, ok := s.refreshing[b.Slug]; ok {
+		return
+	}
+	s.refreshing[b.Slug] = struct{}{}
+	s.refreshes.Add(1)
+
+	go func(ctx context.Context) {
+		defer s.refreshes.Done()
+		defer func() {
+			s.mu.Lock()
+			delete(s.refreshing, b.Slug)
+			s.mu.Unlock()
+		}()
+
+		delay := refreshDelay
+		for range refreshAttempts {
+			t := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return
+			case <-t.C:
+			}
+			delay *= 2
+
+			if _, err := s.scrapeForecast(b); err == nil || errors.Is(err, meteo365.ErrBreakNotFound) {
+				return
+			}
+		}
+	}(s.ctx)
+}
+
+// NearbyBreaks returns stored surf breaks that are located within the given radius
+// from the given surf break sorted by distance. If withRatings is true, the current
+// rating of each surf break is looked up as well, and it is left unknown for the ones
+// whose forecasts cannot be fetched.
+//
+// Only the surf breaks that have been looked up before are known, since scraping the
+// location of every surf break around would take too many requests. It returns no surf
+// breaks if the location of the given surf break is unknown.
+func (s *Service) NearbyBreaks(b meteo365.Break, radiusInKilometers float64, withRatings bool) []NearbyBreak {
+	if b.Location == nil {
+		return nil
+	}
+
+	var nearby []NearbyBreak
+	for _, other := range s.breakStore.All() {
+		if other.ID == b.ID || other.Location == nil {
+			continue
+		}
+
+		distance := geo.DistanceInKilometers(*b.Location, *other.Location)
+		if distance > radiusInKilometers {
+			continue
+		}
+
+		nearby = append(nearby, NearbyBreak{
+			Break:                other,
+			DistanceInKilometers: distance,
+		})
+	}
+
+	if withRatings {
+		forEachConcurrently(nearby, nearbyConcurrency, func(nb *NearbyBreak) {
+			iss, err := s.LatestForecastIssue(nb.Break)
+			if err != nil {
+				return
+			}
+
+			if hf, ok := iss.Current(time.Now()); ok {
+				nb.Rating = &hf.Rating
+			}
+		})
+	}
+
+	sort.Slice(nearby, func(i, j int) bool {
+		return nearby[i].DistanceInKilometers < nearby[j].DistanceInKilometers
+	})
+
+	return nearby
+}
+
+// NearestBreaks returns up to the given number of stored surf breaks that are the
+// closest to the given point sorted by distance.
+func (s *Service) NearestBreaks(p geo.Point, limit int) []NearbyBreak {
+	var nearest []NearbyBreak
+	for _, b := range s.breakStore.All() {
+		if b.Location == nil {
+			continue
+		}
+
+		nearest = append(nearest, NearbyBreak{
+			Break:                b,
+			DistanceInKilometers: geo.DistanceInKilometers(p, *b.Location),
+		})
+	}
+
+	sort.Slice(nearest, func(i, j int) bool {
+		return nearest[i].DistanceInKilometers < nearest[j].DistanceInKilometers
+	})
+
+	if len(nearest) > limit {
+		nearest = nearest[:limit]
+	}
+
+	return nearest
+}
+
+// NearbyBreak holds information about a surf break located near another one.
+type NearbyBreak struct {
+	Break                meteo365.Break
+	DistanceInKilometers float64
+
+	// Rating holds the current rating of the surf break. It is nil if the rating
+	// was not requested or is unknown.
+	Rating *int
+}
+
+// forEachConcurrently executes the given function for a pointer to each of the given
+// values using at most n goroutines at a time.
+func forEachConcurrently[T any](values []T, n int, fn func(*T)) {
+	var (
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, n)
+	)
+	for i := range values {
+		wg.Add(1)
+		sem <- struct{}{}
+
+		go func(v *T) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			fn(v)
+		}(&values[i])
+	}
+	wg.Wait()
+}
@@ -0,0 +1,111 @@
+package ui
+
+import (
+	"strconv"
+	"time"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/components"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/meteo365"
+)
+
+// DigestEmail returns a Node that renders the HTML body of the daily digest email.
+// Email clients ignore external stylesheets, so the styles are inlined.
+func DigestEmail(props DigestEmailProps) Node {
+	return HTML5(HTML5Props{
+		Title: "Surf digest for " + props.Date.Format("Monday, 2 January"),
+		Body: []Node{
+			Style("margin: 0; padding: 24px; background-color: #f8f9fa; font-family: system-ui, -apple-system, 'Segoe UI', Roboto, sans-serif; color: #212529;"),
+			H1(
+				Style("font-size: 20px; font-weight: 400; margin: 0 0 16px 0;"),
+				Text("Surf digest for "),
+				Span(
+					Style("font-weight: 600;"),
+					Text(props.Date.Format("Monday, 2 January")),
+				),
+			),
+			Group(Map(props.Breaks, func(b DigestBreak) Node {
+				return Div(
+					Style("margin: 0 0 24px 0; padding: 16px; background-color: #ffffff; border: 1px solid #dee2e6; border-radius: 8px;"),
+					H2(
+						Style("font-size: 18px; font-weight: 500; margin: 0;"),
+						A(
+							Style("color: #0d6efd; text-decoration: none;"),
+							Href(props.BaseURL+forecastURL(b.Break)),
+							Text(b.Break.Name),
+						),
+					),
+					P(
+						Style("font-size: 13px; opacity: 0.75; margin: 0 0 12px 0;"),
+						Text(b.Break.CountryName),
+					),
+					If(b.Unavailable, P(
+						Style("font-size: 14px; opacity: 0.75; margin: 0;"),
+						Text("The forecast is unavailable at the moment."),
+					)),
+					If(!b.Unavailable, Table(
+						Style("border-collapse: collapse; width: 100%; font-size: 14px;"),
+						THead(
+							Tr(
+								digestHeaderCell(""),
+								digestHeaderCell("Height"),
+								digestHeaderCell("Period"),
+								digestHeaderCell("Wind"),
+								digestHeaderCell("Rating"),
+							),
+						),
+						TBody(
+							Group(Map(b.Days, func(df *meteo365.DailyForecast) Node {
+								s := df.Summary()
+								return Tr(
+									digestCell(Text(df.Timestamp.Format("Mon 2 Jan"))),
+									digestCell(Text(formatFloat(s.MinWaveHeightInMeters)+"–"+formatFloat(s.MaxWaveHeightInMeters)+" m")),
+									digestCell(Text(formatFloat(s.MaxPeriodInSeconds)+" s")),
+									digestCell(Text(formatFloat(s.MaxWindSpeedInKilometersPerHour)+" km/h "+s.PrevailingWindState)),
+									digestCell(Text(strconv.Itoa(s.MaxRating)+"/10")),
+								)
+							})),
+						),
+					)),
+				)
+			})),
+			P(
+				Style("font-size: 12px; opacity: 0.5; text-align: center;"),
+				Text("The location and forecast data is obtained from www.surf-forecast.com via web scraping, it belongs to its original creators, and full credit is given to them."),
+			),
+		},
+	})
+}
+
+// DigestEmailProps holds data needed for rendering the daily digest email.
+type DigestEmailProps struct {
+	Date time.Time
+
+	// BaseURL holds the public URL of the application used for building absolute links.
+	BaseURL string
+	Breaks  []DigestBreak
+}
+
+// DigestBreak holds the upcoming daily forecasts of a surf break included in the digest.
+type DigestBreak struct {
+	Break meteo365.Break
+	Days  []*meteo365.DailyForecast
+
+	// Unavailable is true if the forecast could not be fetched.
+	Unavailable bool
+}
+
+func digestHeaderCell(text string) Node {
+	return Th(
+		Style("padding: 4px 8px; text-align: left; font-weight: 300; opacity: 0.5;"),
+		Text(text),
+	)
+}
+
+func digestCell(children ...Node) Node {
+	return Td(
+		Style("padding: 4px 8px; border-top: 1px solid #dee2e6; white-space: nowrap;"),
+		Group(children),
+	)
+}
@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strconv"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
+	"github.com/ztimes2/glassy/internal/ui/layout"
+)
+
+// ErrorPage returns a Node that renders the page shown when a request fails.
+func ErrorPage(props ErrorPageProps) Node {
+	return layout.Page(layout.Props{
+		Title:  props.Title,
+		Nonce:  props.Nonce,
+		Assets: props.Assets,
+		Main: []Node{
+			Div(
+				Class("d-flex flex-column justify-content-center align-items-center text-center px-3"),
+				P(
+					Class("display-4 fw-light opacity-50 mb-0"),
+					Text(strconv.Itoa(props.StatusCode)),
+				),
+				H1(
+					Class("fs-3 fw-normal mb-2"),
+					Text(props.Title),
+				),
+				P(
+					Class("fw-light mb-3"),
+					Text(props.Message),
+				),
+				A(
+					Class("link-primary link-offset-1 fw-light mb-3"),
+					Href("/search"),
+					Text("Search for surf spots"),
+				),
+				If(
+					props.RequestID != "",
+					P(
+						Class("fw-light opacity-50"),
+						Small(
+							Text("Request ID: "),
+							Code(Text(props.RequestID)),
+						),
+					),
+				),
+			),
+		},
+	})
+}
+
+// ErrorPageProps holds data needed for rendering the error page.
+type ErrorPageProps struct {
+	StatusCode int
+	Title      string
+	Message    string
+
+	// RequestID holds the ID of the failed request, which its details are logged
+	// with.
+	RequestID string
+
+	// Nonce holds the nonce that allows the page's inline styles.
+	Nonce string
+
+	// Assets holds the static files the page links to.
+	Assets *assets.Manifest
+}
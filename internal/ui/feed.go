@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"strconv"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/surf"
+)
+
+// ForecastIssueEntry returns a Node that renders the content of a feed entry announcing
+// a forecast issue. It summarizes the coming days and what changed since the previous
+// issue.
+func ForecastIssueEntry(props ForecastIssueEntryProps) Node {
+	return Group([]Node{
+		H3(Text("Coming days")),
+		Ul(
+			Group(Map(props.ForecastIssue.Daily, func(df *meteo365.DailyForecast) Node {
+				s := df.Summary()
+				return Li(
+					B(Text(df.Timestamp.Format("Mon 2 Jan")+": ")),
+					Text(formatFloat(s.MinWaveHeightInMeters)+"–"+formatFloat(s.MaxWaveHeightInMeters)+" m, "),
+					Text(formatFloat(s.MaxPeriodInSeconds)+" s, "),
+					Text(formatFloat(s.MaxWindSpeedInKilometersPerHour)+" km/h "+s.PrevailingWindState+", "),
+					Text("rated up to "+strconv.Itoa(s.MaxRating)+"/10"),
+				)
+			})),
+		),
+		If(
+			props.HasPrevious,
+			Group([]Node{
+				H3(Text("Changes since the previous issue")),
+				If(
+					len(props.Changes) == 0,
+					P(Text("No changes.")),
+				),
+				If(
+					len(props.Changes) > 0,
+					Ul(
+						Group(Map(props.Changes, func(c surf.DayChange) Node {
+							return Li(
+								B(Text(c.Date.Format("Mon 2 Jan")+": ")),
+								Text(
+									"height "+formatFloat(c.Previous.MaxWaveHeightInMeters)+" → "+formatFloat(c.Current.MaxWaveHeightInMeters)+" m, "+
+										"period "+formatFloat(c.Previous.MaxPeriodInSeconds)+" → "+formatFloat(c.Current.MaxPeriodInSeconds)+" s, "+
+										"wind "+formatFloat(c.Previous.MaxWindSpeedInKilometersPerHour)+" → "+formatFloat(c.Current.MaxWindSpeedInKilometersPerHour)+" km/h, "+
+										"rating "+strconv.Itoa(c.Previous.MaxRating)+" → "+strconv.Itoa(c.Current.MaxRating),
+								),
+							)
+						})),
+					),
+				),
+			}),
+		),
+	})
+}
+
+// ForecastIssueEntryProps holds data needed for rendering a feed entry of a forecast issue.
+type ForecastIssueEntryProps struct {
+	ForecastIssue *meteo365.ForecastIssue
+
+	// HasPrevious indicates whether there is a previous issue that Changes are relative to.
+	HasPrevious bool
+	Changes     []surf.DayChange
+}
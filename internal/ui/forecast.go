@@ -4,192 +4,215 @@ import (
 	"strconv"
 
 	. "github.com/maragudk/gomponents"
+	hx "github.com/maragudk/gomponents-htmx"
 	. "github.com/maragudk/gomponents/components"
 	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
 	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/ui/layout"
 )
 
 // LatestForecastPage returns a Node that renders the latest forecast page.
 func LatestForecastPage(props LatestForecastPageProps) Node {
-	return HTML5(HTML5Props{
-		Title:       props.Break.Name + " - Lighter surf forecasts",
-		Description: "It's like www.surf-forecast.com but lighter.",
+	return layout.Page(layout.Props{
+		Title: props.Break.Name,
 		Head: []Node{
 			Link(
-				Href("/apple-touch-icon.png"),
-				Rel("apple-touch-icon"),
-				Attr("sizes", "180x180"),
+				Href("/breaks/"+strconv.Itoa(props.Break.ID)+"/forecasts.atom"),
+				Rel("alternate"),
+				Type("application/atom+xml"),
+				Title(props.Break.Name+" forecasts"),
 			),
-			Link(
-				Href("/favicon-32x32.png"),
-				Rel("icon"),
-				Attr("sizes", "32x32"),
-				Attr("type", "image/png"),
-			),
-			Link(
-				Href("/favicon-16x16.png"),
-				Rel("icon"),
-				Attr("sizes", "16x16"),
-				Attr("type", "image/png"),
-			),
-			Link(
-				Href("/site.webmanifest"),
-				Rel("manifest"),
-			),
-			Link(
-				Href("https://cdn.jsdelivr.net/npm/bootstrap@5.3.3/dist/css/bootstrap.min.css"),
-				Rel("stylesheet"),
-				Integrity("sha384-QWTKZyjpPEjISv5WaRU9OFeRpok6YctnYmDr5pNlyT2bRjXh0JMhjY6hW+ALEwIH"),
-				CrossOrigin("anonymous"),
-			),
-			Script(Src("https://unpkg.com/htmx.org@2.0.2")),
-			StyleEl(Raw(`
-				/* Modify Bootstrap's vh-100 class to properly support iOS screens. */
-				@supports (-webkit-touch-callout: none) {
-					.vh-100 {
-						height: -webkit-fill-available !important;
-					}
-				}
-				
+			StyleEl(layout.Nonce(props.Nonce), Raw(`
 				table {
 					border-collapse: separate;  
 					border-spacing: 10px 0px;
 				}
+
+				.daily-forecast {
+					margin: 0px -10px;
+				}
+
+				.list-group-item {
+					background-color: transparent !important;
+				}
+				.list-group-item:hover {
+					background-color: var(--bs-gray-200) !important;
+				}
 			`)),
 		},
-		Body: []Node{
-			Class("container bg-light"),
+		HTMX:    true,
+		Flashes: props.flashes(),
+		Nonce:   props.Nonce,
+		Assets:  props.Assets,
+		Main: []Node{
 			Div(
-				Class("d-flex flex-column justify-content-between align-items-center vh-100 gap-4"),
-				Header(),
-				Main(
-					Class("d-flex flex-column justify-content-center align-items-center align-self-stretch gap-2"),
-					Div(
-						Class("d-flex flex-column justify-content-center align-items-center"),
-						H1(
-							Class("fs-3 fw-normal text-center mb-1"),
-							Text(props.Break.Name),
-						),
-						H2(
-							Class("fs-6 fw-light opacity-75 mb-3"),
-							Text(props.Break.CountryName),
-						),
-						Div(
-							mapIndex(props.ForecastIssue.Daily, func(i int, df *meteo365.DailyForecast) Node {
-								return Group([]Node{
-									H3(
-										Class("fs-5 align-self-stretch mb-0 border-top pt-2 px-1"),
-										Span(
-											Class("fw-medium me-1"),
-											Text(props.forecastWeekday(i)),
-										),
-										Span(
-											Class("fw-light"),
-											Text(props.forecastDate(i)),
+				Class("d-flex flex-column justify-content-center align-items-center"),
+				H1(
+					Class("fs-3 fw-normal text-center mb-1"),
+					Text(props.Break.Name),
+				),
+				H2(
+					Class("fs-6 fw-light opacity-75 mb-1"),
+					Text(props.Break.CountryName),
+				),
+				Div(
+					Class("d-flex gap-3 mb-3"),
+					A(
+						Class("link-secondary link-offset-1 fw-light"),
+						Href(forecastHistoryURL(props.Break)),
+						Small(Text("Forecast history")),
+					),
+					A(
+						Class("link-secondary link-offset-1 fw-light"),
+						Href(forecastURL(props.Break)+".ics"),
+						Small(Text("Surf calendar")),
+					),
+					A(
+						Class("link-secondary link-offset-1 fw-light"),
+						Href("/breaks/"+strconv.Itoa(props.Break.ID)+"/forecasts.atom"),
+						Small(Text("Forecast feed")),
+					),
+					A(
+						Class("link-secondary link-offset-1 fw-light"),
+						Href("/breaks/"+strconv.Itoa(props.Break.ID)+"/forecasts/latest?format=csv"),
+						Small(Text("CSV")),
+					),
+					A(
+						Class("link-secondary link-offset-1 fw-light"),
+						Href("/breaks/"+strconv.Itoa(props.Break.ID)+"/forecasts/latest?format=ndjson"),
+						Small(Text("NDJSON")),
+					),
+				),
+				Div(
+					mapIndex(props.ForecastIssue.Daily, func(i int, df *meteo365.DailyForecast) Node {
+						return Group([]Node{
+							H3(
+								Class("fs-5 align-self-stretch mb-0 border-top pt-2 px-1"),
+								Span(
+									Class("fw-medium me-1"),
+									Text(props.forecastWeekday(i)),
+								),
+								Span(
+									Class("fw-light"),
+									Text(props.forecastDate(i)),
+								),
+							),
+							Div(
+								Class("px-1 mb-4 daily-forecast"),
+								Table(
+									Class("table table-bordered"),
+									THead(
+										Tr(
+											Th(
+												Class("fw-light bg-transparent border-0 opacity-50"),
+												Attr("scope", "col"),
+											),
+											Th(
+												Class("fw-light bg-transparent border-0 opacity-50 text-center"),
+												Attr("scope", "col"),
+												Small(Text("Swell")),
+											),
+											Th(
+												Class("fw-light bg-transparent border-0 opacity-50 text-center"),
+												Attr("scope", "col"),
+												Small(Text("Wind")),
+											),
 										),
 									),
-									Div(
-										Class("px-1 mb-4"),
-										Style("margin: 0px -10px;"),
-										Table(
-											Class("table table-bordered"),
-											THead(
-												Tr(
-													Th(
-														Class("fw-light bg-transparent border-0 opacity-50"),
-														Attr("scope", "col"),
-													),
-													Th(
-														Class("fw-light bg-transparent border-0 opacity-50 text-center"),
-														Attr("scope", "col"),
-														Small(Text("Swell")),
-													),
-													Th(
-														Class("fw-light bg-transparent border-0 opacity-50 text-center"),
-														Attr("scope", "col"),
-														Small(Text("Wind")),
-													),
+									TBody(
+										mapIndex(df.Hourly, func(j int, hf meteo365.HourlyForecast) Node {
+											return Tr(
+												Th(
+													Class("fw-light bg-transparent border-0 opacity-50 text-end py-3 px-0 text-nowrap"),
+													Attr("scope", "row"),
+													Small(Text(props.forecastHour(i, j))),
 												),
-											),
-											TBody(
-												mapIndex(df.Hourly, func(j int, hf meteo365.HourlyForecast) Node {
-													return Tr(
-														Th(
-															Class("fw-light bg-transparent border-0 opacity-50 text-end py-3 px-0 text-nowrap"),
-															Attr("scope", "row"),
-															Small(Text(props.forecastHour(i, j))),
+												Td(
+													Classes{
+														"p-3 text-center": true,
+														"border-bottom border-top rounded-top-3 rounded-bottom-3": len(df.Hourly) == 1,                                 // Only one hour is available
+														"border-bottom border-top rounded-top-3":                  len(df.Hourly) > 1 && j == 0,                        // First hour among many
+														"border-top-0 border-bottom rounded-bottom-3":             len(df.Hourly) > 1 && j == len(df.Hourly)-1,         // Last hour among many
+														"border-top-0 border-bottom":                              len(df.Hourly) > 1 && j > 0 && j < len(df.Hourly)-1, // Hours in between many
+													},
+													Div(
+														Class("row"),
+														Div(
+															Class("col text-nowrap"),
+															Text(strconv.FormatFloat(hf.Swells.Primary.WaveHeightInMeters, 'f', -1, 64)),
+															Small(
+																Class("fw-light"),
+																Text(" m"),
+															),
 														),
-														Td(
-															Classes{
-																"p-3 text-center": true,
-																"border-bottom border-top rounded-top-3 rounded-bottom-3": len(df.Hourly) == 1,                                 // Only one hour is available
-																"border-bottom border-top rounded-top-3":                  len(df.Hourly) > 1 && j == 0,                        // First hour among many
-																"border-top-0 border-bottom rounded-bottom-3":             len(df.Hourly) > 1 && j == len(df.Hourly)-1,         // Last hour among many
-																"border-top-0 border-bottom":                              len(df.Hourly) > 1 && j > 0 && j < len(df.Hourly)-1, // Hours in between many
-															},
-															Div(
-																Class("row"),
-																Div(
-																	Class("col text-nowrap"),
-																	Text(strconv.FormatFloat(hf.Swells.Primary.WaveHeightInMeters, 'f', -1, 64)),
-																	Small(
-																		Class("fw-light"),
-																		Text(" m"),
-																	),
-																),
-																Div(
-																	Class("col text-nowrap"),
-																	Text(strconv.FormatFloat(hf.Swells.Primary.PeriodInSeconds, 'f', -1, 64)),
-																	Small(
-																		Class("fw-light"),
-																		Text(" s"),
-																	),
-																),
-																Div(
-																	Class("col text-nowrap"),
-																	Text(strconv.FormatFloat(hf.WaveEnergyInKiloJoules, 'f', -1, 64)),
-																	Small(
-																		Class("fw-light"),
-																		Text(" kJ"),
-																	),
-																),
+														Div(
+															Class("col text-nowrap"),
+															Text(strconv.FormatFloat(hf.Swells.Primary.PeriodInSeconds, 'f', -1, 64)),
+															Small(
+																Class("fw-light"),
+																Text(" s"),
 															),
 														),
-														Td(
-															Classes{
-																"p-3 text-center": true,
-																"border-bottom border-top rounded-top-3 rounded-bottom-3": len(df.Hourly) == 1,                                 // Only one hour is available
-																"border-bottom border-top rounded-top-3":                  len(df.Hourly) > 1 && j == 0,                        // First hour among many
-																"border-top-0 border-bottom rounded-bottom-3":             len(df.Hourly) > 1 && j == len(df.Hourly)-1,         // Last hour among many
-																"border-top-0 border-bottom":                              len(df.Hourly) > 1 && j > 0 && j < len(df.Hourly)-1, // Hours in between many
-															},
-															Div(
-																Class("row"),
-																Div(
-																	Class("col text-nowrap"),
-																	Text(strconv.FormatFloat(hf.Wind.SpeedInKilometersPerHour, 'f', -1, 64)),
-																	Small(
-																		Class("fw-light"),
-																		Text(" km/h"),
-																	),
-																),
-																Div(
-																	Class("col text-nowrap"),
-																	Text(hf.Wind.State),
-																),
+														Div(
+															Class("col text-nowrap"),
+															Text(strconv.FormatFloat(hf.WaveEnergyInKiloJoules, 'f', -1, 64)),
+															Small(
+																Class("fw-light"),
+																Text(" kJ"),
 															),
 														),
-													)
-												})...,
-											),
-										),
+													),
+												),
+												Td(
+													Classes{
+														"p-3 text-center": true,
+														"border-bottom border-top rounded-top-3 rounded-bottom-3": len(df.Hourly) == 1,                                 // Only one hour is available
+														"border-bottom border-top rounded-top-3":                  len(df.Hourly) > 1 && j == 0,                        // First hour among many
+														"border-top-0 border-bottom rounded-bottom-3":             len(df.Hourly) > 1 && j == len(df.Hourly)-1,         // Last hour among many
+														"border-top-0 border-bottom":                              len(df.Hourly) > 1 && j > 0 && j < len(df.Hourly)-1, // Hours in between many
+													},
+													Div(
+														Class("row"),
+														Div(
+															Class("col text-nowrap"),
+															Text(strconv.FormatFloat(hf.Wind.SpeedInKilometersPerHour, 'f', -1, 64)),
+															Small(
+																Class("fw-light"),
+																Text(" km/h"),
+															),
+														),
+														Div(
+															Class("col text-nowrap"),
+															Text(hf.Wind.State),
+														),
+													),
+												),
+											)
+										})...,
 									),
-								})
-							})...,
-						),
+								),
+							),
+						})
+					})...,
+				),
+				H3(
+					Class("fs-5 align-self-stretch mb-0 border-top pt-2 px-1"),
+					Span(
+						Class("fw-medium"),
+						Text("Nearby"),
+					),
+				),
+				Div(
+					Class("align-self-stretch mb-4"),
+					hx.Get("/breaks/"+strconv.Itoa(props.Break.ID)+"/nearby"),
+					hx.Trigger("load"),
+					hx.Swap("innerHTML"),
+					P(
+						Class("fw-light text-center opacity-50 py-2"),
+						Small(Text("Looking for surf spots nearby...")),
 					),
 				),
-				footer(),
 			),
 		},
 	})
@@ -199,6 +222,31 @@ func LatestForecastPage(props LatestForecastPageProps) Node {
 type LatestForecastPageProps struct {
 	Break         meteo365.Break
 	ForecastIssue *meteo365.ForecastIssue
+
+	// Stale is true if the forecast issue is outdated because a newer one could not
+	// be scraped.
+	Stale bool
+
+	// Nonce holds the nonce that allows the page's inline scripts and styles.
+	Nonce string
+
+	// Assets holds the static files the page links to.
+	Assets *assets.Manifest
+}
+
+// flashes returns the flash messages of the page.
+func (p LatestForecastPageProps) flashes() []layout.Flash {
+	if !p.Stale {
+		return nil
+	}
+
+	return []layout.Flash{{
+		Level: layout.FlashWarning,
+		Message: Group([]Node{
+			Text("Stale, issued at " + p.ForecastIssue.IssuedAt.Format("Mon 2 Jan, 3 pm") + ". "),
+			Text("www.surf-forecast.com cannot be reached at the moment, so the forecast will be updated later."),
+		}),
+	}}
 }
 
 // forecastWeekday returns a textual representation of a weekday by a daily forecast index.
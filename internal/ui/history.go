@@ -0,0 +1,193 @@
+package ui
+
+import (
+	"math"
+	"strconv"
+	"time"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/components"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/surf"
+	"github.com/ztimes2/glassy/internal/ui/layout"
+)
+
+// ForecastHistoryPage returns a Node that renders the page showing how the forecast
+// of a day evolved across forecast issues.
+func ForecastHistoryPage(props ForecastHistoryPageProps) Node {
+	return layout.Page(layout.Props{
+		Title:  props.Break.Name + " forecast history",
+		Nonce:  props.Nonce,
+		Assets: props.Assets,
+		Main: []Node{
+			Div(
+				Class("d-flex flex-column justify-content-center align-items-center"),
+				H1(
+					Class("fs-3 fw-normal text-center mb-1"),
+					A(
+						Class("link-dark text-decoration-none"),
+						Href(forecastURL(props.Break)),
+						Text(props.Break.Name),
+					),
+				),
+				H2(
+					Class("fs-6 fw-light opacity-75 mb-3"),
+					Text("Forecast history"),
+				),
+				Ul(
+					Class("nav nav-pills justify-content-center mb-3"),
+					Group(Map(props.Dates, func(d time.Time) Node {
+						return Li(
+							Class("nav-item"),
+							A(
+								Classes{
+									"nav-link py-1 px-2": true,
+									"active":             sameDate(d, props.Date),
+								},
+								Href(forecastHistoryURL(props.Break)+"?date="+d.Format(time.DateOnly)),
+								Small(Text(d.Format("Mon 2 Jan"))),
+							),
+						)
+					})),
+				),
+				If(
+					len(props.Revisions) == 0,
+					P(
+						Class("fw-light text-center opacity-50"),
+						Small(Text("No forecast issues have been archived for this day yet.")),
+					),
+				),
+				If(
+					len(props.Revisions) > 0,
+					Div(
+						Class("table-responsive"),
+						Table(
+							Class("table table-sm align-middle text-center"),
+							THead(
+								Tr(
+									Th(Class("fw-light opacity-50 bg-transparent"), Attr("scope", "col"), Small(Text("Issued"))),
+									Th(Class("fw-light opacity-50 bg-transparent"), Attr("scope", "col"), Small(Text("Height"))),
+									Th(Class("fw-light opacity-50 bg-transparent"), Attr("scope", "col"), Small(Text("Period"))),
+									Th(Class("fw-light opacity-50 bg-transparent"), Attr("scope", "col"), Small(Text("Energy"))),
+									Th(Class("fw-light opacity-50 bg-transparent"), Attr("scope", "col"), Small(Text("Wind"))),
+									Th(Class("fw-light opacity-50 bg-transparent"), Attr("scope", "col"), Small(Text("Rating"))),
+								),
+							),
+							TBody(
+								Group(Map(props.Revisions, func(r surf.DayRevision) Node {
+									var prev meteo365.DailySummary
+									if r.Previous != nil {
+										prev = *r.Previous
+									}
+
+									return Tr(
+										Th(
+											Class("fw-light bg-transparent text-nowrap"),
+											Attr("scope", "row"),
+											Small(Text(r.IssuedAt.Format("Mon 2 Jan, 3 pm"))),
+										),
+										Td(
+											Class("bg-transparent text-nowrap"),
+											Text(formatFloat(r.Summary.MinWaveHeightInMeters)+"–"+formatFloat(r.Summary.MaxWaveHeightInMeters)),
+											Small(Class("fw-light"), Text(" m")),
+											delta(r.Previous != nil, r.Summary.MaxWaveHeightInMeters, prev.MaxWaveHeightInMeters),
+										),
+										Td(
+											Class("bg-transparent text-nowrap"),
+											Text(formatFloat(r.Summary.MaxPeriodInSeconds)),
+											Small(Class("fw-light"), Text(" s")),
+											delta(r.Previous != nil, r.Summary.MaxPeriodInSeconds, prev.MaxPeriodInSeconds),
+										),
+										Td(
+											Class("bg-transparent text-nowrap"),
+											Text(formatFloat(r.Summary.MaxWaveEnergyInKiloJoules)),
+											Small(Class("fw-light"), Text(" kJ")),
+											delta(r.Previous != nil, r.Summary.MaxWaveEnergyInKiloJoules, prev.MaxWaveEnergyInKiloJoules),
+										),
+										Td(
+											Class("bg-transparent text-nowrap"),
+											Text(formatFloat(r.Summary.MaxWindSpeedInKilometersPerHour)),
+											Small(Class("fw-light"), Text(" km/h "+r.Summary.PrevailingWindState)),
+											delta(r.Previous != nil, r.Summary.MaxWindSpeedInKilometersPerHour, prev.MaxWindSpeedInKilometersPerHour),
+										),
+										Td(
+											Class("bg-transparent text-nowrap"),
+											Text(strconv.Itoa(r.Summary.MaxRating)),
+											delta(r.Previous != nil, float64(r.Summary.MaxRating), float64(prev.MaxRating)),
+										),
+									)
+								})),
+							),
+						),
+					),
+				),
+			),
+		},
+	})
+}
+
+// ForecastHistoryPageProps holds data needed for rendering the forecast history page.
+type ForecastHistoryPageProps struct {
+	Break meteo365.Break
+
+	// Dates holds the dates the history can be shown for.
+	Dates []time.Time
+
+	// Date holds the date the history is shown for.
+	Date      time.Time
+	Revisions []surf.DayRevision
+
+	// Nonce holds the nonce that allows the page's inline styles.
+	Nonce string
+
+	// Assets holds the static files the page links to.
+	Assets *assets.Manifest
+}
+
+// delta returns a Node that renders the difference between the current and the previous
+// values of a revision, or nothing if there is no previous value or no difference.
+func delta(hasPrevious bool, current, previous float64) Node {
+	if !hasPrevious || current == previous {
+		return nil
+	}
+
+	d := current - previous
+	text := formatFloat(d)
+	if d > 0 {
+		text = "+" + text
+	}
+
+	return Small(
+		Classes{
+			"d-block fw-light": true,
+			"text-success":     d > 0,
+			"text-danger":      d < 0,
+		},
+		Text(text),
+	)
+}
+
+// formatFloat returns a textual representation of a float rounded to one decimal place
+// with trailing zeros removed.
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(math.Round(f*10)/10, 'f', -1, 64)
+}
+
+// forecastURL returns a URL of the latest forecast page of a surf break.
+func forecastURL(b meteo365.Break) string {
+	return "/breaks/" + strconv.Itoa(b.ID) + "/forecasts/latest"
+}
+
+// forecastHistoryURL returns a URL of the forecast history page of a surf break.
+func forecastHistoryURL(b meteo365.Break) string {
+	return "/breaks/" + strconv.Itoa(b.ID) + "/forecasts/history"
+}
+
+// sameDate checks if the given timestamps have the same year, month and day.
+func sameDate(a, b time.Time) bool {
+	ay, am, ad := a.Date()
+	by, bm, bd := b.Date()
+	return ay == by && am == bm && ad == bd
+}
@@ -0,0 +1,67 @@
+package layout
+
+import (
+	"encoding/json"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
+)
+
+// iconLinks returns a Node that renders links to the icons and the web app manifest.
+func iconLinks(manifest *assets.Manifest) Node {
+	return Group([]Node{
+		Link(
+			Href(manifest.URL("apple-touch-icon.png")),
+			Rel("apple-touch-icon"),
+			Attr("sizes", "180x180"),
+		),
+		Link(
+			Href(manifest.URL("favicon-32x32.png")),
+			Rel("icon"),
+			Attr("sizes", "32x32"),
+			Attr("type", "image/png"),
+		),
+		Link(
+			Href(manifest.URL("favicon-16x16.png")),
+			Rel("icon"),
+			Attr("sizes", "16x16"),
+			Attr("type", "image/png"),
+		),
+		Link(
+			Href(manifest.URL("site.webmanifest")),
+			Rel("manifest"),
+		),
+	})
+}
+
+// bootstrapStylesheet returns a Node that renders a link to Bootstrap's stylesheet.
+func bootstrapStylesheet(manifest *assets.Manifest) Node {
+	return Link(
+		Href(manifest.URL("vendor/bootstrap.min.css")),
+		Rel("stylesheet"),
+	)
+}
+
+// htmxConfig returns a Node that renders the configuration of htmx, which passes the
+// nonce on to the styles and scripts htmx adds to the page.
+func htmxConfig(nonce string) Node {
+	if nonce == "" {
+		return nil
+	}
+
+	config, _ := json.Marshal(map[string]string{
+		"inlineScriptNonce": nonce,
+		"inlineStyleNonce":  nonce,
+	})
+
+	return Meta(
+		Name("htmx-config"),
+		Content(string(config)),
+	)
+}
+
+// htmxScript returns a Node that renders a script element loading htmx.
+func htmxScript(manifest *assets.Manifest) Node {
+	return Script(Src(manifest.URL("vendor/htmx.min.js")))
+}
@@ -0,0 +1,144 @@
+// Package layout provides the layout shared by the pages of the web application.
+package layout
+
+import (
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/components"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
+)
+
+const (
+	siteName        = "Lighter surf forecasts"
+	siteDescription = "It's like www.surf-forecast.com but lighter."
+)
+
+// Page returns a Node that renders a full page with the shared head, header, flash
+// messages and footer around the given main content.
+func Page(props Props) Node {
+	title := siteName
+	if props.Title != "" {
+		title = props.Title + " - " + siteName
+	}
+
+	description := props.Description
+	if description == "" {
+		description = siteDescription
+	}
+
+	footerNode := props.Footer
+	if footerNode == nil {
+		footerNode = footer()
+	}
+
+	return HTML5(HTML5Props{
+		Title:       title,
+		Description: description,
+		Head: []Node{
+			iconLinks(props.Assets),
+			bootstrapStylesheet(props.Assets),
+			If(props.HTMX, htmxConfig(props.Nonce)),
+			If(props.HTMX, htmxScript(props.Assets)),
+			StyleEl(
+				Nonce(props.Nonce),
+				Raw(`
+				/* Modify Bootstrap's vh-100 class to properly support iOS screens. */
+				@supports (-webkit-touch-callout: none) {
+					.vh-100 {
+						height: -webkit-fill-available !important;
+					}
+				}
+			`),
+			),
+			Group(props.Head),
+		},
+		Body: []Node{
+			Class("container bg-light"),
+			Div(
+				Class("d-flex flex-column justify-content-between align-items-center vh-100 gap-4"),
+				Header(props.Nav...),
+				Main(
+					Class("d-flex flex-column justify-content-center align-items-center align-self-stretch gap-2"),
+					Group(Map(props.Flashes, flash)),
+					Group(props.Main),
+				),
+				footerNode,
+			),
+			Group(props.Scripts),
+		},
+	})
+}
+
+// Props holds data needed for rendering a page.
+type Props struct {
+	// Title holds the title of the page, which is followed by the name of the site.
+	// The name of the site is the whole title if it is empty.
+	Title string
+
+	// Description holds the description of the page. The description of the site is
+	// used if it is empty.
+	Description string
+
+	// Head holds page-specific elements of the head such as links and styles.
+	Head []Node
+
+	// HTMX enables htmx on the page.
+	HTMX bool
+
+	// Nav holds the content of the header, which is empty by default.
+	Nav []Node
+
+	// Flashes holds messages shown above the main content.
+	Flashes []Flash
+
+	// Main holds the main content.
+	Main []Node
+
+	// Footer replaces the default footer unless it is nil.
+	Footer Node
+
+	// Scripts holds scripts that run once the page is loaded.
+	Scripts []Node
+
+	// Nonce holds the nonce of the Content-Security-Policy of the response, which
+	// inline scripts and styles of the page must carry to be allowed.
+	Nonce string
+
+	// Assets holds the static files, which are linked by their fingerprinted names.
+	// They are linked by their names as they are if it is nil.
+	Assets *assets.Manifest
+}
+
+// Nonce returns a Node that renders the nonce attribute of an inline script or style.
+// It renders nothing if the nonce is empty.
+func Nonce(nonce string) Node {
+	if nonce == "" {
+		return nil
+	}
+	return Attr("nonce", nonce)
+}
+
+// FlashLevel is the importance of a flash message.
+type FlashLevel string
+
+// Levels of flash messages.
+const (
+	FlashInfo    FlashLevel = "info"
+	FlashWarning FlashLevel = "warning"
+	FlashError   FlashLevel = "danger"
+)
+
+// Flash is a message shown above the main content of a page.
+type Flash struct {
+	Level   FlashLevel
+	Message Node
+}
+
+// flash returns a Node that renders a flash message.
+func flash(f Flash) Node {
+	return Div(
+		Class("alert alert-"+string(f.Level)+" py-2 px-3 mb-0 fw-light"),
+		Attr("role", "status"),
+		Small(f.Message),
+	)
+}
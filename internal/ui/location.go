@@ -0,0 +1,100 @@
+package ui
+
+import (
+	"strconv"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
+	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/ui/layout"
+)
+
+// RegionPage returns a Node that renders the page listing surf breaks of a region.
+func RegionPage(props RegionPageProps) Node {
+	return breakListingPage(props.Region.Name, props.Region.CountryName, props.Region.Breaks, props.Nonce, props.Assets)
+}
+
+// RegionPageProps holds data needed for rendering the region page.
+type RegionPageProps struct {
+	Region meteo365.Region
+	Nonce  string
+	Assets *assets.Manifest
+}
+
+// CountryPage returns a Node that renders the page listing surf breaks of a country.
+func CountryPage(props CountryPageProps) Node {
+	return breakListingPage(props.Country.Name, "", props.Country.Breaks, props.Nonce, props.Assets)
+}
+
+// CountryPageProps holds data needed for rendering the country page.
+type CountryPageProps struct {
+	Country meteo365.Country
+	Nonce   string
+	Assets  *assets.Manifest
+}
+
+// breakListingPage returns a Node that renders a page listing the given surf breaks
+// of a region or a country. The nonce allows the page's inline styles, and the page
+// links to the given static files.
+func breakListingPage(
+	name, countryName string,
+	breaks []meteo365.BreakSummary,
+	nonce string,
+	manifest *assets.Manifest,
+) Node {
+
+	return layout.Page(layout.Props{
+		Title: name,
+		Head: []Node{
+			StyleEl(layout.Nonce(nonce), Raw(`
+				.list-group-item {
+					background-color: transparent !important;
+				}
+				.list-group-item:hover {
+					background-color: var(--bs-gray-200) !important;
+				}
+			`)),
+		},
+		Nonce:  nonce,
+		Assets: manifest,
+		Main: []Node{
+			Div(
+				Class("d-flex flex-column justify-content-center align-items-center"),
+				H1(
+					Class("fs-3 fw-normal text-center mb-1"),
+					Text(name),
+				),
+				If(
+					countryName != "",
+					H2(
+						Class("fs-6 fw-light opacity-75 mb-3"),
+						Text(countryName),
+					),
+				),
+			),
+			Div(
+				Class("row align-self-stretch"),
+				Div(Class("col")),
+				Div(
+					Class("col col-12 col-md-8 col-lg-5 px-3 list-group list-group-flush"),
+					If(
+						len(breaks) == 0,
+						P(
+							Class("fw-light text-center opacity-75"),
+							Text("No surf breaks found."),
+						),
+					),
+					Group(Map(breaks, func(b meteo365.BreakSummary) Node {
+						return listItem(
+							"/breaks/"+strconv.Itoa(b.ID)+"/forecasts/latest",
+							b.Name,
+							name,
+						)
+					})),
+				),
+				Div(Class("col")),
+			),
+		},
+	})
+}
@@ -0,0 +1,52 @@
+package ui
+
+import (
+	"strconv"
+
+	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/surf"
+)
+
+// NearbyBreaks returns a Node that renders a list of nearby surf breaks. It is meant
+// to be loaded into the latest forecast page asynchronously.
+func NearbyBreaks(props NearbyBreaksProps) Node {
+	if len(props.Breaks) == 0 {
+		return P(
+			Class("fw-light text-center opacity-50 py-2"),
+			Small(Text("No surf spots found within "+formatKilometers(props.RadiusInKilometers)+".")),
+		)
+	}
+
+	return Div(
+		Class("list-group list-group-flush"),
+		Group(Map(props.Breaks, func(nb surf.NearbyBreak) Node {
+			return listItem(
+				"/breaks/"+strconv.Itoa(nb.Break.ID)+"/forecasts/latest",
+				nb.Break.Name,
+				nearbyBreakDetails(nb),
+			)
+		})),
+	)
+}
+
+// NearbyBreaksProps holds data needed for rendering the list of nearby surf breaks.
+type NearbyBreaksProps struct {
+	RadiusInKilometers float64
+	Breaks             []surf.NearbyBreak
+}
+
+// nearbyBreakDetails returns a textual representation of a nearby surf break's distance
+// and rating if known.
+func nearbyBreakDetails(nb surf.NearbyBreak) string {
+	details := formatKilometers(nb.DistanceInKilometers) + " away"
+	if nb.Rating != nil {
+		details += " · rated " + strconv.Itoa(*nb.Rating) + "/10 now"
+	}
+	return details
+}
+
+// formatKilometers returns a textual representation of a distance rounded to one decimal place.
+func formatKilometers(km float64) string {
+	return strconv.FormatFloat(km, 'f', 1, 64) + " km"
+}
@@ -5,53 +5,18 @@ import (
 
 	. "github.com/maragudk/gomponents"
 	hx "github.com/maragudk/gomponents-htmx"
-	. "github.com/maragudk/gomponents/components"
 	. "github.com/maragudk/gomponents/html"
+	"github.com/ztimes2/glassy/internal/assets"
 	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/surf"
+	"github.com/ztimes2/glassy/internal/ui/layout"
 )
 
 // SearchPage returns a Node that renders the search page.
 func SearchPage(props SearchPageProps) Node {
-	return HTML5(HTML5Props{
-		Title:       "Lighter surf forecasts",
-		Description: "It's like www.surf-forecast.com but lighter.",
+	return layout.Page(layout.Props{
 		Head: []Node{
-			Link(
-				Href("/apple-touch-icon.png"),
-				Rel("apple-touch-icon"),
-				Attr("sizes", "180x180"),
-			),
-			Link(
-				Href("/favicon-32x32.png"),
-				Rel("icon"),
-				Attr("sizes", "32x32"),
-				Attr("type", "image/png"),
-			),
-			Link(
-				Href("/favicon-16x16.png"),
-				Rel("icon"),
-				Attr("sizes", "16x16"),
-				Attr("type", "image/png"),
-			),
-			Link(
-				Href("/site.webmanifest"),
-				Rel("manifest"),
-			),
-			Link(
-				Href("https://cdn.jsdelivr.net/npm/bootstrap@5.3.3/dist/css/bootstrap.min.css"),
-				Rel("stylesheet"),
-				Integrity("sha384-QWTKZyjpPEjISv5WaRU9OFeRpok6YctnYmDr5pNlyT2bRjXh0JMhjY6hW+ALEwIH"),
-				CrossOrigin("anonymous"),
-			),
-			Script(Src("https://unpkg.com/htmx.org@2.0.2")),
-			StyleEl(Raw(`
-				/* Modify Bootstrap's vh-100 class to properly support iOS screens. */
-				@supports (-webkit-touch-callout: none) {
-					.vh-100 {
-						height: -webkit-fill-available !important;
-					}
-				}
-
+			StyleEl(layout.Nonce(props.Nonce), Raw(`
 				#search-results > * .list-group-item {
 					background-color: transparent !important;
 				}
@@ -60,88 +25,143 @@ func SearchPage(props SearchPageProps) Node {
 				}
 			`)),
 		},
-		Body: []Node{
-			Class("container bg-light"),
+		HTMX:   true,
+		Nonce:  props.Nonce,
+		Assets: props.Assets,
+		Main: []Node{
+			Div(
+				Class("d-flex flex-column justify-content-center align-items-center"),
+				H1(
+					Class("fs-3 fw-light text-center"),
+					Text("It's like "),
+					A(
+						Class("link-primary link-offset-1"),
+						Href("https://www.surf-forecast.com"),
+						Text("surf-forecast.com"),
+					),
+					Text(" but "),
+					Span(
+						Class("fw-semibold fst-italic"),
+						Text("lighter"),
+					),
+				),
+			),
 			Div(
-				Class("d-flex flex-column justify-content-between align-items-center vh-100 gap-4"),
-				Header(),
-				Main(
-					Class("d-flex flex-column justify-content-center align-items-center align-self-stretch gap-2"),
+				Class("row align-self-stretch"),
+				Div(Class("col")),
+				Div(
+					Class("col col-12 col-md-8 col-lg-5"),
 					Div(
-						Class("d-flex flex-column justify-content-center align-items-center"),
-						H1(
-							Class("fs-3 fw-light text-center"),
-							Text("It's like "),
-							A(
-								Class("link-primary link-offset-1"),
-								Href("https://www.surf-forecast.com"),
-								Text("surf-forecast.com"),
-							),
-							Text(" but "),
-							Span(
-								Class("fw-semibold fst-italic"),
-								Text("lighter"),
-							),
+						Class("input-group input-group-lg"),
+						Input(
+							ID("search-bar"),
+							Class("form-control fw-light"),
+							Type("search"),
+							Placeholder("Start typing to find surf spots"),
+							Value(props.SearchQuery),
+							hx.Get("/search"),
+							Name("q"),
+							hx.Select("#search-results"),
+							hx.Trigger("input changed"),
+							hx.Target("#search-results"),
+							hx.Swap("outerHTML"),
+							hx.ReplaceURL("true"),
+						),
+						Button(
+							ID("near-me"),
+							Class("btn btn-outline-secondary fw-light"),
+							Type("button"),
+							Title("Find surf spots near me"),
+							Text("Near me"),
 						),
 					),
-					Div(
-						Class("row align-self-stretch"),
+				),
+				Div(Class("col")),
+			),
+			Div(
+				ID("search-results"),
+				Class("row align-self-stretch"),
+				If(
+					props.NearbySearch,
+					Group([]Node{
 						Div(Class("col")),
 						Div(
-							Class("col col-12 col-md-8 col-lg-5"),
-							Input(
-								ID("search-bar"),
-								Class("form-control form-control-lg fw-light"),
-								Type("search"),
-								Placeholder("Start typing to find surf spots"),
-								Value(props.SearchQuery),
-								hx.Get("/search"),
-								Name("q"),
-								hx.Select("#search-results"),
-								hx.Trigger("input changed"),
-								hx.Target("#search-results"),
-								hx.Swap("outerHTML"),
-								hx.ReplaceURL("true"),
+							Class("col col-12 col-md-8 col-lg-5 px-3 pt-2 list-group list-group-flush"),
+							If(
+								len(props.NearbyBreaks) == 0,
+								P(
+									Class("fw-light text-center opacity-50 py-2"),
+									Small(Text("No known surf spots near you yet. Try searching for one by its name.")),
+								),
 							),
+							Group(Map(props.NearbyBreaks, func(nb surf.NearbyBreak) Node {
+								return listItem(
+									"/breaks/"+strconv.Itoa(nb.Break.ID)+"/forecasts/latest",
+									nb.Break.Name,
+									nb.Break.CountryName+" · "+formatKilometers(nb.DistanceInKilometers)+" away",
+								)
+							})),
 						),
 						Div(Class("col")),
-					),
-					Div(
-						ID("search-results"),
-						Class("row align-self-stretch"),
-						If(
-							len(props.Breaks) > 0,
-							Group([]Node{
-								Div(Class("col")),
-								Div(
-									Class("col col-12 col-md-8 col-lg-5 px-3 pt-2 list-group list-group-flush"),
-									Group(Map(props.Breaks, func(b meteo365.BreakSearchResult) Node {
-										return A(
-											Class("list-group-item list-group-item-action py-2"),
-											Href("/breaks/"+strconv.Itoa(b.ID)+"/forecasts/latest"),
-											H6(
-												Class("mb-0 fs-6"),
-												Text(b.Name),
-											),
-											Small(
-												Class("opacity-75"),
-												Text(b.CountryName),
-											),
-										)
-									})),
-								),
-								Div(Class("col")),
-							}),
+					}),
+				),
+				If(
+					!props.Results.Empty(),
+					Group([]Node{
+						Div(Class("col")),
+						Div(
+							Class("col col-12 col-md-8 col-lg-5 px-3 pt-2 list-group list-group-flush"),
+							Group(Map(props.Results.Countries, func(c meteo365.CountrySearchResult) Node {
+								return listItem(
+									"/countries/"+strconv.Itoa(c.ID),
+									c.Name,
+									"Country",
+								)
+							})),
+							Group(Map(props.Results.Regions, func(r meteo365.RegionSearchResult) Node {
+								return listItem(
+									"/regions/"+strconv.Itoa(r.ID),
+									r.Name,
+									"Region in "+r.CountryName,
+								)
+							})),
+							Group(Map(props.Results.Breaks, func(b meteo365.BreakSearchResult) Node {
+								return listItem(
+									"/breaks/"+strconv.Itoa(b.ID)+"/forecasts/latest",
+									b.Name,
+									b.CountryName,
+								)
+							})),
 						),
-					),
+						Div(Class("col")),
+					}),
 				),
-				footer(),
 			),
+		},
+		Scripts: []Node{
 			Script(
+				layout.Nonce(props.Nonce),
 				If(
-					props.SearchQuery == "",
+					props.SearchQuery == "" && !props.NearbySearch,
 					Raw(`document.getElementById("search-bar").focus();`),
 				),
+				Raw(`
+					const nearMe = document.getElementById("near-me");
+					if (!navigator.geolocation) {
+						nearMe.remove();
+					}
+					nearMe.addEventListener("click", function () {
+						navigator.geolocation.getCurrentPosition(function (position) {
+							const url = "/search/nearby?lat=" + position.coords.latitude + "&lon=" + position.coords.longitude;
+							htmx.ajax("GET", url, {
+								source: "#search-results",
+								target: "#search-results",
+								select: "#search-results",
+								swap: "outerHTML",
+							});
+						});
+					});
+				`),
 			),
 		},
 	})
@@ -150,5 +170,16 @@ func SearchPage(props SearchPageProps) Node {
 // SearchPageProps holds data needed for rendering the search page.
 type SearchPageProps struct {
 	SearchQuery string
-	Breaks      []meteo365.BreakSearchResult
+	Results     meteo365.SearchResults
+
+	// NearbySearch indicates whether the surf breaks near the user's location were
+	// searched for, in which case NearbyBreaks holds the results.
+	NearbySearch bool
+	NearbyBreaks []surf.NearbyBreak
+
+	// Nonce holds the nonce that allows the page's inline scripts and styles.
+	Nonce string
+
+	// Assets holds the static files the page links to.
+	Assets *assets.Manifest
 }
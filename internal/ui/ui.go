@@ -2,6 +2,7 @@ package ui
 
 import (
 	. "github.com/maragudk/gomponents"
+	. "github.com/maragudk/gomponents/html"
 )
 
 // mapIndex is like [github.com/maragudk/gomponents.Map] but also passes the index to the callback function.
@@ -12,3 +13,19 @@ func mapIndex[T any](ts []T, fn func(int, T) Node) []Node {
 	}
 	return nodes
 }
+
+// listItem returns a Node that renders a link of a list group with a title and a subtitle.
+func listItem(href, title, subtitle string) Node {
+	return A(
+		Class("list-group-item list-group-item-action py-2"),
+		Href(href),
+		H6(
+			Class("mb-0 fs-6"),
+			Text(title),
+		),
+		Small(
+			Class("opacity-75"),
+			Text(subtitle),
+		),
+	)
+}
@@ -1,29 +1,347 @@
 package main
 
 import (
+	"context"
 	"embed"
+	"errors"
+	"fmt"
 	"io/fs"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
 
+	"github.com/ztimes2/glassy/internal/alert"
+	"github.com/ztimes2/glassy/internal/alert/webhook"
+	"github.com/ztimes2/glassy/internal/assets"
+	"github.com/ztimes2/glassy/internal/cli"
+	"github.com/ztimes2/glassy/internal/config"
+	"github.com/ztimes2/glassy/internal/digest"
+	"github.com/ztimes2/glassy/internal/health"
 	"github.com/ztimes2/glassy/internal/meteo365"
+	"github.com/ztimes2/glassy/internal/metrics"
 	"github.com/ztimes2/glassy/internal/router"
+	"github.com/ztimes2/glassy/internal/store"
+	"github.com/ztimes2/glassy/internal/surf"
 )
 
 //go:embed all:static
 var static embed.FS
 
 func main() {
-	scraper := meteo365.NewScraper()
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, "glassy:", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command given by the command-line arguments. It starts the web server
+// if no command is given.
+func run(args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") && !isHelp(args[0]) {
+		return serve(args)
+	}
+
+	switch args[0] {
+	case "serve":
+		return serve(args[1:])
+	case "search", "forecast":
+		// The commands have flags of their own, so the configuration is only loaded
+		// from the environment variables and the config file.
+		cfg, err := config.Load(nil)
+		if err != nil {
+			return err
+		}
+
+		scraper := newScraper(cfg)
+		if args[0] == "search" {
+			return cli.Search(scraper, args[1:], os.Stdout)
+		}
+		return cli.Forecast(scraper, args[1:], os.Stdout)
+	case "help", "-h", "-help", "--help":
+		fmt.Print(cli.Usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], cli.Usage)
+	}
+}
+
+func isHelp(arg string) bool {
+	return arg == "-h" || arg == "-help" || arg == "--help"
+}
+
+// newScraper initializes a scraper for the command-line commands.
+func newScraper(cfg config.Config) *meteo365.Scraper {
+	return meteo365.NewScraper(scraperOptions(cfg, cfg.NewLogger(os.Stderr), nil))
+}
+
+func scraperOptions(cfg config.Config, logger *slog.Logger, registry *metrics.Registry) meteo365.Options {
+	maxRetries := cfg.Scraper.MaxRetries
+	if maxRetries == 0 {
+		// Zero stands for the default in meteo365.Options.
+		maxRetries = -1
+	}
+
+	return meteo365.Options{
+		BaseURL:          cfg.Scraper.BaseURL,
+		Timeout:          cfg.Scraper.Timeout,
+		MaxRetries:       maxRetries,
+		CircuitThreshold: cfg.Scraper.CircuitThreshold,
+		CircuitCooldown:  cfg.Scraper.CircuitCooldown,
+		RateLimit:        cfg.Scraper.RateLimit,
+		RateBurst:        cfg.Scraper.RateBurst,
+		MaxConcurrency:   cfg.Scraper.MaxConcurrency,
+		MaxQueue:         cfg.Scraper.MaxQueue,
+		UserAgent:        cfg.Scraper.UserAgent,
+		RespectRobots:    cfg.Scraper.RespectRobots,
+		Logger:           logger,
+		Metrics:          registry,
+	}
+}
+
+// serve starts the web server along with the background workers using the
+// configuration loaded from the given command-line arguments.
+func serve(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return err
+	}
+
+	logger := cfg.NewLogger(os.Stderr)
+	slog.SetDefault(logger)
+
+	logger.Info("loaded config", "config", cfg)
+
+	if cfg.PublicURL == config.Default().PublicURL {
+		logger.Warn("public url is not configured, links in emails, calendars and feeds point to the default", "public_url", cfg.PublicURL)
+	}
+
+	registry := metrics.NewRegistry()
+
+	breaks, err := store.OpenBreakStore(filepath.Join(cfg.Storage.DataDir, "breaks.json"))
+	if err != nil {
+		return err
+	}
+
+	archive := store.OpenForecastArchive(filepath.Join(cfg.Storage.DataDir, "forecasts"), cfg.Storage.ForecastRetention)
+
+	scraper := meteo365.NewScraper(scraperOptions(cfg, logger, registry))
+
+	service := surf.NewService(scraper, breaks, archive, cfg.Cache.TTL)
+	service.RegisterMetrics(registry)
+
+	rules, err := alert.OpenRuleStore(filepath.Join(cfg.Storage.DataDir, "alert-rules.json"))
+	if err != nil {
+		return err
+	}
+
+	fired, err := alert.OpenFiredStore(filepath.Join(cfg.Storage.DataDir, "alert-fired.json"))
+	if err != nil {
+		return err
+	}
+
+	notifiers := map[string]alert.Notifier{"log": alert.NewLogNotifier(logger)}
+
+	var webhooks *webhook.Notifier
+	if len(cfg.Alerts.WebhookURLs) > 0 {
+		webhooks = webhook.NewNotifier(cfg.Alerts.WebhookURLs, cfg.Alerts.WebhookSecret)
+		for name, n := range webhooks.Targets() {
+			notifiers[name] = n
+		}
+	}
+
+	// The delivery log reveals where matches are posted to, so it is only exposed
+	// on demand.
+	var deliveries *webhook.Notifier
+	if cfg.Alerts.ExposeDeliveries {
+		deliveries = webhooks
+	}
 
-	assets, err := fs.Sub(static, "static")
+	scheduler := alert.NewScheduler(
+		service,
+		rules,
+		fired,
+		notifiers,
+		cfg.Alerts.Interval,
+		func(err error) { logger.Error("could not evaluate alert rules", "error", err) },
+	)
+
+	sender, err := newDigestSender(service, cfg.Digest, cfg.PublicURL)
+	if err != nil {
+		return err
+	}
+
+	checker := health.NewChecker()
+	checker.Add("config", health.CheckFunc(func(ctx context.Context) error { return cfg.Validate() }))
+	checker.Add("storage", health.DirWritable(cfg.Storage.DataDir))
+
+	var canary *health.Canary
+	if cfg.Health.CanaryBreakID > 0 {
+		canary = health.NewCanary(scraper, cfg.Health.CanaryBreakID, cfg.Health.CanaryInterval)
+		checker.Add("upstream", canary)
+	} else {
+		checker.Add("upstream", health.Skipped("no canary break is configured"))
+	}
+
+	staticFS, err := fs.Sub(static, "static")
+	if err != nil {
+		return err
+	}
+
+	manifest, err := assets.NewManifest(staticFS)
+	if err != nil {
+		return fmt.Errorf("could not load static files: %w", err)
+	}
+
+	// Pages are broken without the vendored files, so they must be embedded.
+	for _, name := range []string{"vendor/bootstrap.min.css", "vendor/htmx.min.js"} {
+		if _, err := fs.Stat(staticFS, name); err != nil {
+			return fmt.Errorf("could not find static file %s, run scripts/vendor-assets.sh to fetch it: %w", name, err)
+		}
+	}
+
+	r, err := router.New(router.Options{
+		Service:     service,
+		Rules:       rules,
+		AlertsToken: cfg.Alerts.APIToken,
+		Webhooks:    deliveries,
+		PublicURL:   cfg.PublicURL,
+		HSTS:        cfg.Server.HSTS,
+		Assets:      manifest,
+		CacheMaxAge: cfg.Cache.MaxAge,
+		Logger:      logger,
+		Metrics:     registry,
+		Health:      checker,
+	})
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:              cfg.ListenAddr,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// The background workers stop once the context is canceled.
+	var workers sync.WaitGroup
+	workers.Add(2)
+	go func() {
+		defer workers.Done()
+		service.Run(ctx)
+	}()
+	go func() {
+		defer workers.Done()
+		scheduler.Run(ctx)
+	}()
+	if sender != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			sender.Run(ctx)
+		}()
+	}
+	if canary != nil {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			canary.Run(ctx)
+		}()
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		logger.Info("listening", "addr", cfg.ListenAddr)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-serveErr:
+		// The server failed on its own (i.e. the address is already in use), so the
+		// workers are stopped without waiting for them.
+		stop()
+		return fmt.Errorf("could not serve: %w", err)
+	case <-ctx.Done():
+	}
+
+	// Restore the default behaviour of the signals, so that a second one kills the
+	// process right away.
+	stop()
+	logger.Info("shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("could not drain in-flight requests: %w", err)
+	}
+
+	workersDone := make(chan struct{})
+	go func() {
+		workers.Wait()
+		close(workersDone)
+	}()
+
+	select {
+	case <-workersDone:
+	case <-shutdownCtx.Done():
+		return errors.New("could not stop background workers in time")
+	}
+
+	logger.Info("shut down gracefully")
+	return nil
+}
+
+// newDigestSender initializes a digest.Sender using the given configuration, which
+// links to the application at the given public URL. It returns nil if no SMTP server
+// is configured.
+func newDigestSender(service *surf.Service, cfg config.Digest, publicURL string) (*digest.Sender, error) {
+	if cfg.SMTPHost == "" {
+		return nil, nil
+	}
+
+	tlsMode, err := digest.ParseTLSMode(cfg.SMTPTLS)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	r := router.New(scraper, assets)
+	at, err := cfg.TimeOfDay()
+	if err != nil {
+		return nil, err
+	}
 
-	err = http.ListenAndServe(":8080", r)
+	location, err := cfg.Location()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
+
+	mailer := digest.NewMailer(digest.SMTPConfig{
+		Host:     cfg.SMTPHost,
+		Port:     cfg.SMTPPort,
+		Username: cfg.SMTPUsername,
+		Password: cfg.SMTPPassword,
+		TLS:      tlsMode,
+		From:     cfg.From,
+	})
+
+	return digest.NewSender(
+		service,
+		mailer,
+		cfg.BreakIDs,
+		cfg.To,
+		publicURL,
+		at,
+		location,
+		func(err error) { slog.Error("digest failed", "error", err) },
+	), nil
 }
@@ -0,0 +1,47 @@
+#!/bin/sh
+# Fetches the third-party files that pages link to into static/vendor, so that they
+# are served along with the other static files. Each file is checked against its
+# known SHA-384 hash before it is written, and files that already match their hashes
+# are left as they are.
+set -eu
+
+cd "$(dirname "$0")/.."
+
+mkdir -p static/vendor
+
+fetch() {
+	url=$1
+	file=$2
+	hash=$3
+
+	if [ -f "static/vendor/$file" ] &&
+		[ "$(openssl dgst -sha384 -binary "static/vendor/$file" | openssl base64 -A)" = "$hash" ]; then
+		echo "static/vendor/$file is up to date"
+		return
+	fi
+
+	tmp=$(mktemp)
+	trap 'rm -f "$tmp"' EXIT
+
+	curl -fsSL -o "$tmp" "$url"
+
+	actual=$(openssl dgst -sha384 -binary "$tmp" | openssl base64 -A)
+	if [ "$actual" != "$hash" ]; then
+		echo "unexpected hash of $url: sha384-$actual" >&2
+		exit 1
+	fi
+
+	mv "$tmp" "static/vendor/$file"
+	chmod 644 "static/vendor/$file"
+	echo "fetched static/vendor/$file"
+}
+
+fetch \
+	https://cdn.jsdelivr.net/npm/bootstrap@5.3.3/dist/css/bootstrap.min.css \
+	bootstrap.min.css \
+	QWTKZyjpPEjISv5WaRU9OFeRpok6YctnYmDr5pNlyT2bRjXh0JMhjY6hW+ALEwIH
+
+fetch \
+	https://unpkg.com/htmx.org@2.0.2/dist/htmx.min.js \
+	htmx.min.js \
+	Y7hw+L/jvKeWIRRkqWYfPcvVxHzVzn5REgzbawhxAuQGwX1XWe70vji+VSeHOThJ